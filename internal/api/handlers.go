@@ -1,32 +1,40 @@
 package api
 
 import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hypr-technologies/hyprknot/internal/knot"
+	"github.com/hypr-technologies/hyprknot/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
 // Handler represents the API handler
 type Handler struct {
-	knotClient *knot.Client
-	logger     *logrus.Logger
+	backend knot.Backend
+	metrics *metrics.Metrics
+	logger  *logrus.Logger
 }
 
 // NewHandler creates a new API handler
-func NewHandler(knotClient *knot.Client, logger *logrus.Logger) *Handler {
+func NewHandler(backend knot.Backend, m *metrics.Metrics, logger *logrus.Logger) *Handler {
 	return &Handler{
-		knotClient: knotClient,
-		logger:     logger,
+		backend: backend,
+		metrics: m,
+		logger:  logger,
 	}
 }
 
 // HealthCheck handles health check requests
 func (h *Handler) HealthCheck(c *gin.Context) {
 	// Check KnotDNS health
-	if err := h.knotClient.CheckHealth(); err != nil {
+	if err := h.backend.CheckHealth(c.Request.Context()); err != nil {
 		h.logger.Errorf("Health check failed: %v", err)
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"status": "unhealthy",
@@ -42,14 +50,64 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 	})
 }
 
-// GetZones handles GET /api/v1/zones
+// Metrics handles GET /metrics in the Prometheus text format. knotd's own
+// counters are read at scrape time and exported as gauges.
+func (h *Handler) Metrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	h.metrics.Write(c.Writer)
+
+	up := 1.0
+	stats, err := h.backend.GetStats(c.Request.Context())
+	if err != nil {
+		h.logger.Errorf("Failed to get knotd statistics: %v", err)
+		up = 0
+	}
+
+	gauges := []metrics.Gauge{{
+		Name:  "hyprknot_knotd_up",
+		Help:  "Whether knotd statistics could be read.",
+		Value: up,
+	}}
+	for _, stat := range stats {
+		labels := make(map[string]string)
+		if stat.Zone != "" {
+			labels["zone"] = stat.Zone
+		}
+		if stat.Index != "" {
+			labels["index"] = stat.Index
+		}
+		gauges = append(gauges, metrics.Gauge{
+			Name:   "knotd_" + metrics.SanitizeName(stat.Section) + "_" + metrics.SanitizeName(stat.Item),
+			Help:   "knotd counter " + stat.Section + "." + stat.Item + ".",
+			Labels: labels,
+			Value:  stat.Value,
+		})
+	}
+	metrics.WriteGauges(c.Writer, gauges)
+}
+
+// GetZones handles GET /api/v1/zones?status=true|false
 func (h *Handler) GetZones(c *gin.Context) {
-	zones, err := h.knotClient.GetZones()
+	if c.Query("status") == "true" {
+		statuses, err := h.backend.GetZoneStatuses(c.Request.Context())
+		if err != nil {
+			h.logger.Errorf("Failed to get zone status: %v", err)
+			h.respondError(c, err, "Failed to retrieve zone status")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"zones": statuses,
+		})
+		return
+	}
+
+	zones, err := h.backend.GetZones(c.Request.Context())
 	if err != nil {
 		h.logger.Errorf("Failed to get zones: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve zones",
-		})
+		h.respondError(c, err, "Failed to retrieve zones")
 		return
 	}
 
@@ -58,28 +116,94 @@ func (h *Handler) GetZones(c *gin.Context) {
 	})
 }
 
-// GetRecords handles GET /api/v1/zones/:zone/records
+// GetZoneStatus handles GET /api/v1/zones/:zone/status
+func (h *Handler) GetZoneStatus(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	status, err := h.backend.GetZoneStatus(c.Request.Context(), zone)
+	if err != nil {
+		h.logger.Errorf("Failed to get status of zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to retrieve zone status")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// CreateZone handles POST /api/v1/zones
+func (h *Handler) CreateZone(c *gin.Context) {
+	var req knot.CreateZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	zone, err := knot.ToASCII(req.Zone)
+	if err != nil {
+		invalidParam(c, "zone", err.Error())
+		return
+	}
+	req.Zone = zone
+
+	if err := h.backend.CreateZone(c.Request.Context(), req.Zone); err != nil {
+		h.logger.Errorf("Failed to create zone %s: %v", req.Zone, err)
+		h.respondError(c, err, "Failed to create zone")
+		return
+	}
+
+	h.logger.Infof("Created zone %s", req.Zone)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Zone created successfully",
+		"zone":         req.Zone,
+		"zone_unicode": knot.ToUnicode(req.Zone),
+	})
+}
+
+// DeleteZone handles DELETE /api/v1/zones/:zone
+func (h *Handler) DeleteZone(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	purge := c.Query("purge") == "true"
+	if err := h.backend.DeleteZone(c.Request.Context(), zone, purge); err != nil {
+		h.logger.Errorf("Failed to delete zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to delete zone")
+		return
+	}
+
+	h.logger.Infof("Deleted zone %s", zone)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Zone deleted successfully",
+	})
+}
+
+// GetRecords handles GET /api/v1/zones/:zone/records?name=&type=
 func (h *Handler) GetRecords(c *gin.Context) {
 	zone := c.Param("zone")
 	if zone == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Zone parameter is required",
-		})
+		invalidParam(c, "zone", "Zone parameter is required")
 		return
 	}
 
-	records, err := h.knotClient.GetRecords(zone)
+	// Optional filters are pushed down to KnotDNS
+	name := c.Query("name")
+	recordType := knot.RecordType(strings.ToUpper(c.Query("type")))
+	if recordType != "" && !knot.IsValidRecordType(string(recordType)) {
+		invalidParam(c, "type", "Invalid record type")
+		return
+	}
+
+	records, err := h.backend.FindRecords(c.Request.Context(), zone, name, recordType)
 	if err != nil {
 		h.logger.Errorf("Failed to get records for zone %s: %v", zone, err)
-		if strings.Contains(err.Error(), "zone not allowed") {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access to zone not allowed",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve records",
-		})
+		h.respondError(c, err, "Failed to retrieve records")
 		return
 	}
 
@@ -89,6 +213,183 @@ func (h *Handler) GetRecords(c *gin.Context) {
 	})
 }
 
+// Media types offered by the zone export, the zone file first
+const (
+	mimeZoneFile = "text/dns"
+	mimeJSON     = "application/json"
+	mimeYAML     = "application/yaml"
+	mimeCSV      = "text/csv"
+)
+
+// exportFormats maps the format query parameter of the export to media types
+var exportFormats = map[string]string{
+	"zone": mimeZoneFile,
+	"json": mimeJSON,
+	"yaml": mimeYAML,
+	"csv":  mimeCSV,
+}
+
+// ExportZone handles GET /api/v1/zones/:zone/export. The format is picked by
+// the format query parameter or else by the Accept header, and defaults to
+// an RFC 1035 zone file.
+func (h *Handler) ExportZone(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	var format string
+	if name := c.Query("format"); name != "" {
+		var ok bool
+		if format, ok = exportFormats[strings.ToLower(name)]; !ok {
+			invalidParam(c, "format", "Invalid format, use zone, json, yaml or csv")
+			return
+		}
+	} else {
+		format = c.NegotiateFormat(mimeZoneFile, mimeJSON, mimeYAML, "application/x-yaml", mimeCSV, "text/plain")
+	}
+
+	export, err := h.backend.ExportZone(c.Request.Context(), zone)
+	if err != nil {
+		h.logger.Errorf("Failed to export zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to export zone")
+		return
+	}
+
+	filename := strings.TrimSuffix(export.Name, ".")
+	switch format {
+	case mimeJSON:
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.JSON(http.StatusOK, export)
+	case mimeYAML, "application/x-yaml":
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.yaml"`)
+		c.YAML(http.StatusOK, export)
+	case mimeCSV:
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := writeRecordsCSV(c.Writer, export.Records); err != nil {
+			h.logger.Errorf("Failed to write CSV export of zone %s: %v", zone, err)
+		}
+	case "":
+		problem(c, http.StatusNotAcceptable, codeInvalidRequest, "Export is available as text/dns, application/json, application/yaml and text/csv")
+	default:
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.zone"`)
+		c.Header("Content-Type", mimeZoneFile+"; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := export.WriteMasterFile(c.Writer); err != nil {
+			h.logger.Errorf("Failed to write zone file export of zone %s: %v", zone, err)
+		}
+	}
+}
+
+// writeRecordsCSV writes records as CSV with a header row. Data holds the
+// complete record data, including the MX preference.
+func writeRecordsCSV(w io.Writer, records []knot.DNSRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"name", "fqdn", "type", "ttl", "data"}); err != nil {
+		return err
+	}
+	for _, record := range records {
+		row := []string{record.Name, record.FQDN, string(record.Type), strconv.FormatUint(uint64(record.TTL), 10), record.RData()}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// maxZoneFileSize bounds the zone files accepted by ImportZone
+const maxZoneFileSize = 32 << 20
+
+// ImportZone handles POST /api/v1/zones/:zone/import?mode=merge|replace
+// with a zone file as request body
+func (h *Handler) ImportZone(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	mode := c.DefaultQuery("mode", knot.ImportModeMerge)
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxZoneFileSize)
+
+	ctx, dry := dryRunContext(c)
+	result, err := h.backend.ImportZone(ctx, zone, body, mode)
+	if err != nil {
+		h.logger.Errorf("Failed to import zone file into zone %s: %v", zone, err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem(c, http.StatusRequestEntityTooLarge, codeInvalidRequest, "Zone file is too large")
+			return
+		}
+		h.respondError(c, err, "Failed to import zone file, no changes were made")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// PlanSync handles POST /api/v1/zones/:zone/sync/plan
+func (h *Handler) PlanSync(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	var req knot.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	plan, err := h.backend.PlanSync(c.Request.Context(), zone, &req)
+	if err != nil {
+		h.logger.Errorf("Failed to plan sync of zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to plan sync")
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// ApplySync handles PUT /api/v1/zones/:zone/sync
+func (h *Handler) ApplySync(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	var req knot.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	ctx, dry := dryRunContext(c)
+	plan, err := h.backend.ApplySync(ctx, zone, &req)
+	if err != nil {
+		h.logger.Errorf("Failed to sync zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to sync zone, no changes were made")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, plan)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
 // GetRecord handles GET /api/v1/zones/:zone/records/:name/:type
 func (h *Handler) GetRecord(c *gin.Context) {
 	zone := c.Param("zone")
@@ -96,37 +397,19 @@ func (h *Handler) GetRecord(c *gin.Context) {
 	recordType := knot.RecordType(strings.ToUpper(c.Param("type")))
 
 	if zone == "" || name == "" || recordType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Zone, name, and type parameters are required",
-		})
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "Zone, name, and type parameters are required")
 		return
 	}
 
 	if !knot.IsValidRecordType(string(recordType)) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid record type",
-		})
+		invalidParam(c, "type", "Invalid record type")
 		return
 	}
 
-	record, err := h.knotClient.GetRecord(zone, name, recordType)
+	record, err := h.backend.GetRecord(c.Request.Context(), zone, name, recordType)
 	if err != nil {
 		h.logger.Errorf("Failed to get record %s %s in zone %s: %v", name, recordType, zone, err)
-		if strings.Contains(err.Error(), "zone not allowed") {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access to zone not allowed",
-			})
-			return
-		}
-		if strings.Contains(err.Error(), "record not found") {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Record not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve record",
-		})
+		h.respondError(c, err, "Failed to retrieve record")
 		return
 	}
 
@@ -137,39 +420,31 @@ func (h *Handler) GetRecord(c *gin.Context) {
 func (h *Handler) CreateRecord(c *gin.Context) {
 	zone := c.Param("zone")
 	if zone == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Zone parameter is required",
-		})
+		invalidParam(c, "zone", "Zone parameter is required")
 		return
 	}
 
 	var req knot.CreateRecordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
-		})
+		invalidBody(c, err)
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		h.respondError(c, err, "Invalid record")
 		return
 	}
 
 	record := req.ToRecord()
-	if err := h.knotClient.CreateRecord(zone, record); err != nil {
+	ctx, dry := dryRunContext(c)
+	if err := h.backend.CreateRecord(ctx, zone, record); err != nil {
 		h.logger.Errorf("Failed to create record in zone %s: %v", zone, err)
-		if strings.Contains(err.Error(), "zone not allowed") {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access to zone not allowed",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create record",
-		})
+		h.respondError(c, err, "Failed to create record")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, record)
 		return
 	}
 
@@ -184,49 +459,42 @@ func (h *Handler) UpdateRecord(c *gin.Context) {
 	recordType := knot.RecordType(strings.ToUpper(c.Param("type")))
 
 	if zone == "" || name == "" || recordType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Zone, name, and type parameters are required",
-		})
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "Zone, name, and type parameters are required")
 		return
 	}
 
 	if !knot.IsValidRecordType(string(recordType)) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid record type",
-		})
+		invalidParam(c, "type", "Invalid record type")
 		return
 	}
 
 	var req knot.UpdateRecordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
-		})
+		invalidBody(c, err)
 		return
 	}
 
-	if err := h.knotClient.UpdateRecord(zone, name, recordType, &req); err != nil {
+	ctx, dry := dryRunContext(c)
+	if err := h.backend.UpdateRecord(ctx, zone, name, recordType, &req); err != nil {
 		h.logger.Errorf("Failed to update record %s %s in zone %s: %v", name, recordType, zone, err)
-		if strings.Contains(err.Error(), "zone not allowed") {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access to zone not allowed",
-			})
-			return
-		}
-		if strings.Contains(err.Error(), "record not found") {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Record not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update record",
-		})
+		h.respondError(c, err, "Failed to update record")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, nil)
+		return
+	}
+
+	// A matched update changes one value of a larger RRset, so return the
+	// whole set rather than an arbitrary member of it
+	if req.Match != nil {
+		h.respondWithRRSet(c, zone, name, recordType, "Record updated successfully")
 		return
 	}
 
 	// Get updated record to return
-	updatedRecord, err := h.knotClient.GetRecord(zone, name, recordType)
+	updatedRecord, err := h.backend.GetRecord(c.Request.Context(), zone, name, recordType)
 	if err != nil {
 		h.logger.Errorf("Failed to get updated record: %v", err)
 		c.JSON(http.StatusOK, gin.H{
@@ -239,43 +507,38 @@ func (h *Handler) UpdateRecord(c *gin.Context) {
 	c.JSON(http.StatusOK, updatedRecord)
 }
 
-// DeleteRecord handles DELETE /api/v1/zones/:zone/records/:name/:type
+// DeleteRecord handles DELETE /api/v1/zones/:zone/records/:name/:type. With
+// a data query parameter only that exact record is deleted.
 func (h *Handler) DeleteRecord(c *gin.Context) {
 	zone := c.Param("zone")
 	name := c.Param("name")
 	recordType := knot.RecordType(strings.ToUpper(c.Param("type")))
 
 	if zone == "" || name == "" || recordType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Zone, name, and type parameters are required",
-		})
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "Zone, name, and type parameters are required")
 		return
 	}
 
 	if !knot.IsValidRecordType(string(recordType)) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid record type",
-		})
+		invalidParam(c, "type", "Invalid record type")
 		return
 	}
 
-	if err := h.knotClient.DeleteRecord(zone, name, recordType); err != nil {
+	var err error
+	ctx, dry := dryRunContext(c)
+	if data := c.Query("data"); data != "" {
+		err = h.backend.RemoveRRSetValue(ctx, zone, name, recordType, data)
+	} else {
+		err = h.backend.DeleteRecord(ctx, zone, name, recordType)
+	}
+	if err != nil {
 		h.logger.Errorf("Failed to delete record %s %s in zone %s: %v", name, recordType, zone, err)
-		if strings.Contains(err.Error(), "zone not allowed") {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access to zone not allowed",
-			})
-			return
-		}
-		if strings.Contains(err.Error(), "record not found") {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Record not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete record",
-		})
+		h.respondError(c, err, "Failed to delete record")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, nil)
 		return
 	}
 
@@ -285,27 +548,487 @@ func (h *Handler) DeleteRecord(c *gin.Context) {
 	})
 }
 
+// GetRRSet handles GET /api/v1/zones/:zone/rrsets/:name/:type
+func (h *Handler) GetRRSet(c *gin.Context) {
+	zone := c.Param("zone")
+	name := c.Param("name")
+	recordType := knot.RecordType(strings.ToUpper(c.Param("type")))
+
+	if zone == "" || name == "" || recordType == "" {
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "Zone, name, and type parameters are required")
+		return
+	}
+
+	if !knot.IsValidRecordType(string(recordType)) {
+		invalidParam(c, "type", "Invalid record type")
+		return
+	}
+
+	rrset, err := h.backend.GetRRSet(c.Request.Context(), zone, name, recordType)
+	if err != nil {
+		h.logger.Errorf("Failed to get RRset %s %s in zone %s: %v", name, recordType, zone, err)
+		h.respondError(c, err, "Failed to retrieve RRset")
+		return
+	}
+
+	c.JSON(http.StatusOK, rrset)
+}
+
+// ReplaceRRSet handles PUT /api/v1/zones/:zone/rrsets/:name/:type
+func (h *Handler) ReplaceRRSet(c *gin.Context) {
+	zone := c.Param("zone")
+	name := c.Param("name")
+	recordType := knot.RecordType(strings.ToUpper(c.Param("type")))
+
+	if zone == "" || name == "" || recordType == "" {
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "Zone, name, and type parameters are required")
+		return
+	}
+
+	if !knot.IsValidRecordType(string(recordType)) {
+		invalidParam(c, "type", "Invalid record type")
+		return
+	}
+
+	var req knot.ReplaceRRSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	rrset := &knot.RRSet{Name: name, Type: recordType, TTL: req.TTL, Values: req.Values}
+	if err := rrset.Validate(); err != nil {
+		h.respondError(c, err, "Invalid RRset")
+		return
+	}
+
+	ctx, dry := dryRunContext(c)
+	if err := h.backend.ReplaceRRSet(ctx, zone, rrset); err != nil {
+		h.logger.Errorf("Failed to replace RRset %s %s in zone %s: %v", name, recordType, zone, err)
+		h.respondError(c, err, "Failed to replace RRset")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, nil)
+		return
+	}
+
+	h.respondWithRRSet(c, zone, name, recordType, "RRset replaced successfully")
+}
+
+// AddRRSetValue handles POST /api/v1/zones/:zone/rrsets/:name/:type/values
+func (h *Handler) AddRRSetValue(c *gin.Context) {
+	zone := c.Param("zone")
+	name := c.Param("name")
+	recordType := knot.RecordType(strings.ToUpper(c.Param("type")))
+
+	if zone == "" || name == "" || recordType == "" {
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "Zone, name, and type parameters are required")
+		return
+	}
+
+	if !knot.IsValidRecordType(string(recordType)) {
+		invalidParam(c, "type", "Invalid record type")
+		return
+	}
+
+	var req knot.RRSetValueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	ctx, dry := dryRunContext(c)
+	if err := h.backend.AddRRSetValue(ctx, zone, name, recordType, req.TTL, req.Value); err != nil {
+		h.logger.Errorf("Failed to add value to RRset %s %s in zone %s: %v", name, recordType, zone, err)
+		h.respondError(c, err, "Failed to add value to RRset")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, nil)
+		return
+	}
+
+	h.respondWithRRSet(c, zone, name, recordType, "Value added successfully")
+}
+
+// RemoveRRSetValue handles DELETE /api/v1/zones/:zone/rrsets/:name/:type/values?value=...
+func (h *Handler) RemoveRRSetValue(c *gin.Context) {
+	zone := c.Param("zone")
+	name := c.Param("name")
+	recordType := knot.RecordType(strings.ToUpper(c.Param("type")))
+	value := c.Query("value")
+
+	if zone == "" || name == "" || recordType == "" || value == "" {
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "Zone, name, type and value parameters are required")
+		return
+	}
+
+	if !knot.IsValidRecordType(string(recordType)) {
+		invalidParam(c, "type", "Invalid record type")
+		return
+	}
+
+	ctx, dry := dryRunContext(c)
+	if err := h.backend.RemoveRRSetValue(ctx, zone, name, recordType, value); err != nil {
+		h.logger.Errorf("Failed to remove value from RRset %s %s in zone %s: %v", name, recordType, zone, err)
+		h.respondError(c, err, "Failed to remove value from RRset")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, nil)
+		return
+	}
+
+	h.logger.Infof("Removed value from RRset %s %s in zone %s", name, recordType, zone)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Value removed successfully",
+	})
+}
+
+// ApplyChanges handles POST /api/v1/zones/:zone/changes
+func (h *Handler) ApplyChanges(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	var req knot.ChangesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	ctx, dry := dryRunContext(c)
+	if err := h.backend.ApplyChanges(ctx, zone, req.Changes); err != nil {
+		h.logger.Errorf("Failed to apply changes to zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to apply changes, no changes were made")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, nil)
+		return
+	}
+
+	h.logger.Infof("Applied %d changes to zone %s", len(req.Changes), zone)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Changes applied successfully",
+		"zone":    zone,
+		"applied": len(req.Changes),
+	})
+}
+
+// dryRunContext returns the context to run a write with. For requests with
+// dry_run=true it also returns the DryRun that the write fills in instead of
+// committing.
+func dryRunContext(c *gin.Context) (context.Context, *knot.DryRun) {
+	if c.Query("dry_run") != "true" {
+		return c.Request.Context(), nil
+	}
+	dry := &knot.DryRun{Removed: []knot.DNSRecord{}, Added: []knot.DNSRecord{}}
+	return knot.WithDryRun(c.Request.Context(), dry), dry
+}
+
+// respondDryRun returns what a write made in a dry run would have changed,
+// along with the result the write would have had, if any
+func respondDryRun(c *gin.Context, zone string, dry *knot.DryRun, result any) {
+	response := gin.H{
+		"dry_run":        true,
+		"zone":           zone,
+		"removed":        dry.Removed,
+		"added":          dry.Added,
+		"semantic_check": dry.Check,
+	}
+	if result != nil {
+		response["result"] = result
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// respondWithRRSet returns the current state of an RRset after a change,
+// falling back to a plain message if it cannot be read back
+func (h *Handler) respondWithRRSet(c *gin.Context, zone, name string, recordType knot.RecordType, message string) {
+	rrset, err := h.backend.GetRRSet(c.Request.Context(), zone, name, recordType)
+	if err != nil {
+		h.logger.Errorf("Failed to get updated RRset: %v", err)
+		c.JSON(http.StatusOK, gin.H{
+			"message": message,
+		})
+		return
+	}
+
+	h.logger.Infof("Changed RRset %s %s in zone %s", name, recordType, zone)
+	c.JSON(http.StatusOK, rrset)
+}
+
+// GetSOA handles GET /api/v1/zones/:zone/soa
+func (h *Handler) GetSOA(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	soa, err := h.backend.GetSOA(c.Request.Context(), zone)
+	if err != nil {
+		h.logger.Errorf("Failed to get SOA of zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to retrieve SOA")
+		return
+	}
+
+	c.JSON(http.StatusOK, soa)
+}
+
+// UpdateSOA handles PUT /api/v1/zones/:zone/soa
+func (h *Handler) UpdateSOA(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	var req knot.UpdateSOARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	ctx, dry := dryRunContext(c)
+	soa, err := h.backend.UpdateSOA(ctx, zone, &req)
+	if err != nil {
+		h.logger.Errorf("Failed to update SOA of zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to update SOA")
+		return
+	}
+
+	if dry != nil {
+		respondDryRun(c, zone, dry, soa)
+		return
+	}
+
+	h.logger.Infof("Updated SOA of zone %s, serial %d", zone, soa.Serial)
+	c.JSON(http.StatusOK, soa)
+}
+
+// SignZone handles POST /api/v1/zones/:zone/dnssec/sign
+func (h *Handler) SignZone(c *gin.Context) {
+	h.runDNSSECCommand(c, "Zone signing started", h.backend.SignZone)
+}
+
+// RolloverKey handles POST /api/v1/zones/:zone/dnssec/rollover/:key
+func (h *Handler) RolloverKey(c *gin.Context) {
+	keyType := c.Param("key")
+	h.runDNSSECCommand(c, "Key rollover started", func(ctx context.Context, zone string) error {
+		return h.backend.RolloverKey(ctx, zone, keyType)
+	})
+}
+
+// SubmitKSK handles POST /api/v1/zones/:zone/dnssec/ksk-submitted
+func (h *Handler) SubmitKSK(c *gin.Context) {
+	h.runDNSSECCommand(c, "KSK submission confirmed", h.backend.SubmitKSK)
+}
+
+// runDNSSECCommand runs a DNSSEC control command for the zone of the
+// request and maps its errors to responses
+func (h *Handler) runDNSSECCommand(c *gin.Context, message string, fn func(ctx context.Context, zone string) error) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	if err := fn(c.Request.Context(), zone); err != nil {
+		h.logger.Errorf("DNSSEC command failed for zone %s: %v", zone, err)
+		h.respondError(c, err, "DNSSEC command failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"zone":    zone,
+	})
+}
+
+// GetDNSSECRecords handles GET /api/v1/zones/:zone/dnssec/keys
+func (h *Handler) GetDNSSECRecords(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	records, err := h.backend.GetDNSSECRecords(c.Request.Context(), zone)
+	if err != nil {
+		h.logger.Errorf("Failed to get DNSSEC records of zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to retrieve DNSSEC records")
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+// GetDS handles GET /api/v1/zones/:zone/ds?digest=sha256|sha384
+func (h *Handler) GetDS(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	var digestType uint8
+	switch strings.ToLower(c.DefaultQuery("digest", "sha256")) {
+	case "sha256", "2":
+		digestType = knot.DigestSHA256
+	case "sha384", "4":
+		digestType = knot.DigestSHA384
+	default:
+		invalidParam(c, "digest", "Invalid digest, use sha256 or sha384")
+		return
+	}
+
+	dsRecords, err := h.backend.GetDS(c.Request.Context(), zone, digestType)
+	if err != nil {
+		h.logger.Errorf("Failed to derive DS records of zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to derive DS records")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"zone":  zone,
+		"ds":    dsRecords,
+		"count": len(dsRecords),
+	})
+}
+
+// FreezeZone handles POST /api/v1/zones/:zone/freeze
+func (h *Handler) FreezeZone(c *gin.Context) {
+	h.runZoneCommand(c, "Zone frozen, changes are rejected until it is thawed", h.backend.FreezeZone)
+}
+
+// ThawZone handles POST /api/v1/zones/:zone/thaw
+func (h *Handler) ThawZone(c *gin.Context) {
+	h.runZoneCommand(c, "Zone thawed", h.backend.ThawZone)
+}
+
+// FlushZone handles POST /api/v1/zones/:zone/flush
+func (h *Handler) FlushZone(c *gin.Context) {
+	h.runZoneCommand(c, "Zone flushed", h.backend.FlushZone)
+}
+
+// PurgeZone handles POST /api/v1/zones/:zone/purge?confirm=:zone
+func (h *Handler) PurgeZone(c *gin.Context) {
+	zone := c.Param("zone")
+	confirm := c.Query("confirm")
+	if !strings.EqualFold(strings.TrimSuffix(confirm, "."), strings.TrimSuffix(zone, ".")) {
+		invalidParam(c, "confirm", "Purging removes all zone data, repeat the zone name in the confirm parameter")
+		return
+	}
+
+	h.runZoneCommand(c, "Zone purged", h.backend.PurgeZone)
+}
+
+// RefreshZone handles POST /api/v1/zones/:zone/refresh
+func (h *Handler) RefreshZone(c *gin.Context) {
+	h.runZoneCommand(c, "Zone refresh started", h.backend.RefreshZone)
+}
+
+// RetransferZone handles POST /api/v1/zones/:zone/retransfer
+func (h *Handler) RetransferZone(c *gin.Context) {
+	h.runZoneCommand(c, "Zone retransfer started", h.backend.RetransferZone)
+}
+
+// NotifyZone handles POST /api/v1/zones/:zone/notify
+func (h *Handler) NotifyZone(c *gin.Context) {
+	h.runZoneCommand(c, "Zone notify sent", h.backend.NotifyZone)
+}
+
+// runZoneCommand runs a zone control command for the zone of the request
+// and maps its errors to responses
+func (h *Handler) runZoneCommand(c *gin.Context, message string, fn func(ctx context.Context, zone string) error) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	if err := fn(c.Request.Context(), zone); err != nil {
+		h.logger.Errorf("Zone command failed for zone %s: %v", zone, err)
+		h.respondError(c, err, "Zone command failed")
+		return
+	}
+
+	h.logger.Infof("%s: %s", message, zone)
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"zone":    zone,
+	})
+}
+
+// SetMaintenance handles PUT /api/v1/zones/:zone/maintenance
+func (h *Handler) SetMaintenance(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		invalidParam(c, "zone", "Zone parameter is required")
+		return
+	}
+
+	var req knot.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	if err := h.backend.SetMaintenance(zone, *req.Enabled); err != nil {
+		h.logger.Errorf("Failed to set maintenance of zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to set maintenance")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"zone":        zone,
+		"maintenance": *req.Enabled,
+	})
+}
+
+// RejectInMaintenance runs before handlers that change a zone and rejects
+// the request with 423 Locked while the zone is in maintenance
+func (h *Handler) RejectInMaintenance(c *gin.Context) {
+	zone := c.Param("zone")
+	if zone == "" {
+		c.Next()
+		return
+	}
+
+	maintenance, err := h.backend.InMaintenance(c.Request.Context(), zone)
+	if err != nil {
+		h.logger.Errorf("Failed to check maintenance of zone %s: %v", zone, err)
+		h.respondError(c, err, "Failed to check zone maintenance")
+		return
+	}
+	if maintenance {
+		problem(c, http.StatusLocked, codeMaintenance, "Zone "+zone+" is in maintenance, changes are not accepted")
+		return
+	}
+
+	c.Next()
+}
+
 // ReloadZone handles POST /api/v1/zones/:zone/reload
 func (h *Handler) ReloadZone(c *gin.Context) {
 	zone := c.Param("zone")
 	if zone == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Zone parameter is required",
-		})
+		invalidParam(c, "zone", "Zone parameter is required")
 		return
 	}
 
-	if err := h.knotClient.ReloadZone(zone); err != nil {
+	if err := h.backend.ReloadZone(c.Request.Context(), zone); err != nil {
 		h.logger.Errorf("Failed to reload zone %s: %v", zone, err)
-		if strings.Contains(err.Error(), "zone not allowed") {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access to zone not allowed",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to reload zone",
-		})
+		h.respondError(c, err, "Failed to reload zone")
 		return
 	}
 
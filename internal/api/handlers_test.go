@@ -0,0 +1,455 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hypr-technologies/hyprknot/internal/config"
+	"github.com/hypr-technologies/hyprknot/internal/knot"
+	"github.com/hypr-technologies/hyprknot/internal/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestRouter returns the API router over an in-memory backend that
+// allows example.com and its subdomains and holds www A 192.0.2.1 in
+// example.com
+func newTestRouter(t *testing.T) (*gin.Engine, knot.Backend) {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	backend := knot.NewMemoryBackend([]string{"example.com"}, knot.Options{AllowedZones: []string{"example.com"}}, logger)
+	record := &knot.DNSRecord{Name: "www", Type: knot.RecordTypeA, TTL: 300, Data: "192.0.2.1"}
+	if err := backend.CreateRecord(context.Background(), "example.com", record); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+
+	cfg := &config.Config{Log: config.LogConfig{Level: "warn"}}
+	return SetupRoutes(cfg, backend, metrics.New(), logger), backend
+}
+
+// serve sends a request with an optional JSON body to router
+func serve(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// wwwValues returns the values of the www A RRset of example.com
+func wwwValues(t *testing.T, backend knot.Backend) []string {
+	t.Helper()
+
+	rrset, err := backend.GetRRSet(context.Background(), "example.com", "www", knot.RecordTypeA)
+	if err != nil {
+		t.Fatalf("GetRRSet: %v", err)
+	}
+	return rrset.Values
+}
+
+func TestHandlers(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+
+		// Problem code and field of error responses
+		code  string
+		field string
+
+		// Text the response body must contain
+		contains string
+
+		// Values of www A after the request, if it changes them
+		values []string
+	}{
+		{name: "health", method: "GET", path: "/health", status: http.StatusOK},
+		{name: "list zones", method: "GET", path: "/api/v1/zones", status: http.StatusOK, contains: "example.com"},
+		{name: "zone status", method: "GET", path: "/api/v1/zones/example.com/status", status: http.StatusOK, contains: `"master"`},
+		{
+			name: "zone not allowed", method: "GET", path: "/api/v1/zones/example.net/records",
+			status: http.StatusForbidden, code: "zone_not_allowed",
+		},
+		{
+			name: "create zone without template name servers", method: "POST", path: "/api/v1/zones",
+			body:   `{"zone": "new.example.com"}`,
+			status: http.StatusServiceUnavailable, code: "zone_template_incomplete",
+		},
+
+		// Records
+		{name: "list records", method: "GET", path: "/api/v1/zones/example.com/records", status: http.StatusOK, contains: "192.0.2.1"},
+		{
+			name: "list records of invalid type", method: "GET", path: "/api/v1/zones/example.com/records?type=BOGUS",
+			status: http.StatusBadRequest, code: "validation_failed", field: "type",
+		},
+		{name: "get record", method: "GET", path: "/api/v1/zones/example.com/records/www/A", status: http.StatusOK, contains: "192.0.2.1"},
+		{
+			name: "get missing record", method: "GET", path: "/api/v1/zones/example.com/records/nope/A",
+			status: http.StatusNotFound, code: "record_not_found",
+		},
+		{
+			name: "create record", method: "POST", path: "/api/v1/zones/example.com/records",
+			body:   `{"name": "www", "type": "A", "ttl": 300, "data": "192.0.2.2"}`,
+			status: http.StatusCreated, values: []string{"192.0.2.1", "192.0.2.2"},
+		},
+		{
+			name: "create record without name", method: "POST", path: "/api/v1/zones/example.com/records",
+			body:   `{"type": "A", "ttl": 300, "data": "192.0.2.2"}`,
+			status: http.StatusBadRequest, code: "invalid_request", field: "name",
+		},
+		{
+			name: "create record with invalid data", method: "POST", path: "/api/v1/zones/example.com/records",
+			body:   `{"name": "www", "type": "A", "ttl": 300, "data": "not-an-address"}`,
+			status: http.StatusBadRequest, code: "validation_failed",
+		},
+		{
+			name: "create record with lowercase type and invalid data", method: "POST", path: "/api/v1/zones/example.com/records",
+			body:   `{"name": "www", "type": "a", "ttl": 300, "data": "not-an-ip"}`,
+			status: http.StatusBadRequest, code: "validation_failed", field: "data",
+		},
+		{
+			name: "create lowercase mx without priority", method: "POST", path: "/api/v1/zones/example.com/records",
+			body:   `{"name": "@", "type": "mx", "ttl": 300, "data": "mail.example.net"}`,
+			status: http.StatusBadRequest, code: "validation_failed", field: "priority",
+		},
+		{
+			name: "create record with white space in name", method: "POST", path: "/api/v1/zones/example.com/records",
+			body:   `{"name": "foo bar", "type": "A", "ttl": 300, "data": "192.0.2.2"}`,
+			status: http.StatusBadRequest, code: "validation_failed", field: "name",
+		},
+		{
+			name: "update record", method: "PUT", path: "/api/v1/zones/example.com/records/www/A",
+			body:   `{"data": "192.0.2.5"}`,
+			status: http.StatusOK, values: []string{"192.0.2.5"},
+		},
+		{
+			name: "delete record", method: "DELETE", path: "/api/v1/zones/example.com/records/mail/A",
+			status: http.StatusNotFound, code: "record_not_found",
+		},
+
+		// RRsets
+		{name: "get rrset", method: "GET", path: "/api/v1/zones/example.com/rrsets/www/A", status: http.StatusOK, contains: "192.0.2.1"},
+		{
+			name: "replace rrset", method: "PUT", path: "/api/v1/zones/example.com/rrsets/www/A",
+			body:   `{"ttl": 300, "values": ["192.0.2.3", "192.0.2.4"]}`,
+			status: http.StatusOK, values: []string{"192.0.2.3", "192.0.2.4"},
+		},
+		{
+			name: "replace rrset without values", method: "PUT", path: "/api/v1/zones/example.com/rrsets/www/A",
+			body:   `{"ttl": 300}`,
+			status: http.StatusBadRequest, code: "invalid_request", field: "values",
+		},
+		{
+			name: "add rrset value", method: "POST", path: "/api/v1/zones/example.com/rrsets/www/A/values",
+			body:   `{"ttl": 300, "value": "192.0.2.3"}`,
+			status: http.StatusOK, values: []string{"192.0.2.1", "192.0.2.3"},
+		},
+		{
+			name: "remove rrset value without value", method: "DELETE", path: "/api/v1/zones/example.com/rrsets/www/A/values",
+			status: http.StatusBadRequest, code: "invalid_request",
+		},
+
+		// Change sets
+		{
+			name: "apply changes", method: "POST", path: "/api/v1/zones/example.com/changes",
+			body:   `{"changes": [{"op": "add", "name": "www", "type": "A", "ttl": 300, "values": ["192.0.2.6"]}]}`,
+			status: http.StatusOK, values: []string{"192.0.2.1", "192.0.2.6"},
+		},
+		{
+			name: "apply changes with invalid value", method: "POST", path: "/api/v1/zones/example.com/changes",
+			body:   `{"changes": [{"op": "add", "name": "www", "type": "A", "ttl": 300, "values": ["bogus"]}]}`,
+			status: http.StatusBadRequest, code: "validation_failed", field: "changes[0].values",
+		},
+		{
+			name: "apply changes without name", method: "POST", path: "/api/v1/zones/example.com/changes",
+			body:   `{"changes": [{"op": "add", "type": "A", "values": ["192.0.2.6"]}]}`,
+			status: http.StatusBadRequest, code: "validation_failed", field: "changes[0].name",
+		},
+		{
+			name: "apply changes removing a missing RRset", method: "POST", path: "/api/v1/zones/example.com/changes",
+			body:   `{"changes": [{"op": "remove", "name": "nothere", "type": "A"}]}`,
+			status: http.StatusNotFound, code: "record_not_found", contains: "nothere.example.com. A",
+		},
+		{
+			name: "apply changes removing a missing value", method: "POST", path: "/api/v1/zones/example.com/changes",
+			body:   `{"changes": [{"op": "remove", "name": "www", "type": "A", "values": ["192.0.2.77"]}]}`,
+			status: http.StatusConflict, code: "change_conflict", values: []string{"192.0.2.1"},
+		},
+
+		// SOA, DNSSEC and maintenance
+		{name: "get soa", method: "GET", path: "/api/v1/zones/example.com/soa", status: http.StatusOK, contains: "ns1.example.com."},
+		{
+			name: "update soa", method: "PUT", path: "/api/v1/zones/example.com/soa",
+			body:   `{"refresh": 7200}`,
+			status: http.StatusOK, contains: `"refresh":7200`,
+		},
+		{
+			name: "ds without ksk", method: "GET", path: "/api/v1/zones/example.com/ds",
+			status: http.StatusNotFound, code: "record_not_found",
+		},
+		{
+			name: "ds with invalid digest", method: "GET", path: "/api/v1/zones/example.com/ds?digest=md5",
+			status: http.StatusBadRequest, code: "validation_failed", field: "digest",
+		},
+		{
+			name: "rollover of invalid key", method: "POST", path: "/api/v1/zones/example.com/dnssec/rollover/csk",
+			status: http.StatusBadRequest, code: "validation_failed", field: "key",
+		},
+		{
+			name: "purge without confirmation", method: "POST", path: "/api/v1/zones/example.com/purge",
+			status: http.StatusBadRequest, code: "validation_failed", field: "confirm",
+		},
+		{
+			name: "maintenance without enabled", method: "PUT", path: "/api/v1/zones/example.com/maintenance",
+			body:   `{}`,
+			status: http.StatusBadRequest, code: "invalid_request", field: "enabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, backend := newTestRouter(t)
+
+			w := serve(router, tt.method, tt.path, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body: %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.code != "" {
+				var p Problem
+				if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+					t.Fatalf("decoding problem: %v; body: %s", err, w.Body)
+				}
+				if p.Code != tt.code {
+					t.Errorf("code = %q, want %q", p.Code, tt.code)
+				}
+				if tt.field != "" && (len(p.Errors) == 0 || p.Errors[0].Field != tt.field) {
+					t.Errorf("errors = %+v, want field %q", p.Errors, tt.field)
+				}
+			}
+			if tt.contains != "" && !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("body does not contain %s: %s", tt.contains, w.Body)
+			}
+
+			want := tt.values
+			if want == nil {
+				want = []string{"192.0.2.1"}
+			}
+			if got := wwwValues(t, backend); !reflect.DeepEqual(got, want) {
+				t.Errorf("www A = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestHandlersDryRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		added   int
+		removed int
+	}{
+		{
+			name: "create record", method: "POST", path: "/api/v1/zones/example.com/records?dry_run=true",
+			body:  `{"name": "www", "type": "A", "ttl": 300, "data": "192.0.2.2"}`,
+			added: 1,
+		},
+		{
+			name: "update record", method: "PUT", path: "/api/v1/zones/example.com/records/www/A?dry_run=true",
+			body:  `{"data": "192.0.2.5"}`,
+			added: 1, removed: 1,
+		},
+		{
+			name: "delete record", method: "DELETE", path: "/api/v1/zones/example.com/records/www/A?dry_run=true",
+			removed: 1,
+		},
+		{
+			name: "replace rrset", method: "PUT", path: "/api/v1/zones/example.com/rrsets/www/A?dry_run=true",
+			body:  `{"ttl": 300, "values": ["192.0.2.3", "192.0.2.4"]}`,
+			added: 2, removed: 1,
+		},
+		{
+			name: "add rrset value", method: "POST", path: "/api/v1/zones/example.com/rrsets/www/A/values?dry_run=true",
+			body:  `{"ttl": 300, "value": "192.0.2.3"}`,
+			added: 1,
+		},
+		{
+			name: "remove rrset value", method: "DELETE", path: "/api/v1/zones/example.com/rrsets/www/A/values?value=192.0.2.1&dry_run=true",
+			removed: 1,
+		},
+		{
+			name: "apply changes", method: "POST", path: "/api/v1/zones/example.com/changes?dry_run=true",
+			body:  `{"changes": [{"op": "replace", "name": "www", "type": "A", "ttl": 300, "values": ["192.0.2.6"]}]}`,
+			added: 1, removed: 1,
+		},
+		{
+			name: "update soa", method: "PUT", path: "/api/v1/zones/example.com/soa?dry_run=true",
+			body:  `{"refresh": 7200}`,
+			added: 1, removed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, backend := newTestRouter(t)
+			before, err := backend.FindRecords(context.Background(), "example.com", "", "")
+			if err != nil {
+				t.Fatalf("FindRecords: %v", err)
+			}
+
+			w := serve(router, tt.method, tt.path, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body)
+			}
+
+			var resp struct {
+				DryRun  bool                `json:"dry_run"`
+				Added   []knot.DNSRecord    `json:"added"`
+				Removed []knot.DNSRecord    `json:"removed"`
+				Check   *knot.SemanticCheck `json:"semantic_check"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding response: %v; body: %s", err, w.Body)
+			}
+			if !resp.DryRun {
+				t.Errorf("response is not a dry run: %s", w.Body)
+			}
+			if len(resp.Added) != tt.added || len(resp.Removed) != tt.removed {
+				t.Errorf("added %d and removed %d records, want %d and %d", len(resp.Added), len(resp.Removed), tt.added, tt.removed)
+			}
+			if resp.Check == nil || !resp.Check.Passed {
+				t.Errorf("semantic check = %+v, want passed", resp.Check)
+			}
+
+			after, err := backend.FindRecords(context.Background(), "example.com", "", "")
+			if err != nil {
+				t.Fatalf("FindRecords: %v", err)
+			}
+			if !reflect.DeepEqual(after, before) {
+				t.Errorf("dry run changed the zone:\n%+v\nwant\n%+v", after, before)
+			}
+		})
+	}
+}
+
+func TestHandlersMaintenance(t *testing.T) {
+	router, backend := newTestRouter(t)
+
+	w := serve(router, "PUT", "/api/v1/zones/example.com/maintenance", `{"enabled": true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	w = serve(router, "POST", "/api/v1/zones/example.com/records", `{"name": "www", "type": "A", "ttl": 300, "data": "192.0.2.2"}`)
+	if w.Code != http.StatusLocked {
+		t.Errorf("write in maintenance: status = %d, want %d", w.Code, http.StatusLocked)
+	}
+	if w = serve(router, "GET", "/api/v1/zones/example.com/records", ""); w.Code != http.StatusOK {
+		t.Errorf("read in maintenance: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := wwwValues(t, backend); !reflect.DeepEqual(got, []string{"192.0.2.1"}) {
+		t.Errorf("www A = %q, want it unchanged", got)
+	}
+}
+
+func TestMaintenanceGuardsEveryWrite(t *testing.T) {
+	// Routes that take a zone and do not change it, or that are needed to
+	// end maintenance
+	open := map[string]bool{
+		"POST /api/v1/zones/:zone/sync/plan":  true,
+		"POST /api/v1/zones/:zone/notify":     true,
+		"POST /api/v1/zones/:zone/freeze":     true,
+		"POST /api/v1/zones/:zone/thaw":       true,
+		"PUT /api/v1/zones/:zone/maintenance": true,
+	}
+	params := strings.NewReplacer(":zone", "example.com", ":name", "www", ":type", "A", ":key", "ksk")
+
+	router, backend := newTestRouter(t)
+	for _, route := range router.Routes() {
+		if !strings.Contains(route.Path, ":zone") {
+			continue
+		}
+		route := route.Method + " " + route.Path
+		method, path, _ := strings.Cut(route, " ")
+
+		t.Run(route, func(t *testing.T) {
+			// thawing or toggling maintenance in an earlier run ends it
+			if err := backend.SetMaintenance("example.com", true); err != nil {
+				t.Fatalf("SetMaintenance: %v", err)
+			}
+			w := serve(router, method, params.Replace(path), "")
+			locked := w.Code == http.StatusLocked
+			if want := method != "GET" && !open[route]; locked != want {
+				t.Errorf("status = %d, want 423 Locked: %t; body: %s", w.Code, want, w.Body)
+			}
+		})
+	}
+}
+
+func TestFrozenZoneIsInMaintenance(t *testing.T) {
+	router, backend := newTestRouter(t)
+	// Frozen in knotd without going through the API, as with knotc
+	if err := backend.FreezeZone(context.Background(), "example.com"); err != nil {
+		t.Fatalf("FreezeZone: %v", err)
+	}
+
+	w := serve(router, "DELETE", "/api/v1/zones/example.com/records/www/A", "")
+	if w.Code != http.StatusLocked {
+		t.Errorf("status = %d, want %d; body: %s", w.Code, http.StatusLocked, w.Body)
+	}
+	if values := wwwValues(t, backend); len(values) != 1 {
+		t.Errorf("www values = %q, want the record kept", values)
+	}
+}
+
+func TestRespondErrorContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "deadline exceeded", err: fmt.Errorf("zone-commit failed: %w", context.DeadlineExceeded), status: http.StatusGatewayTimeout, code: codeTimeout},
+		{name: "canceled", err: fmt.Errorf("zone-commit failed: %w", context.Canceled), status: statusClientClosedRequest, code: codeCanceled},
+		{name: "other", err: fmt.Errorf("zone-commit failed"), status: http.StatusInternalServerError, code: codeInternal},
+	}
+
+	h := &Handler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/v1/zones/example.com/changes", nil)
+
+			h.respondError(c, tt.err, "Failed to apply changes")
+			var body Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if w.Code != tt.status || body.Code != tt.code || body.Title == "" {
+				t.Errorf("response = %d %q %q, want %d %q", w.Code, body.Title, body.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
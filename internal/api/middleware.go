@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hypr-technologies/hyprknot/internal/knot"
+	"github.com/hypr-technologies/hyprknot/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,10 +20,7 @@ func AuthMiddleware(apiKeys []string, enabled bool) gin.HandlerFunc {
 		}
 
 		if len(apiKeys) == 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Authentication is enabled but no API keys are configured",
-			})
-			c.Abort()
+			problem(c, http.StatusInternalServerError, codeNotConfigured, "Authentication is enabled but no API keys are configured")
 			return
 		}
 
@@ -36,10 +35,7 @@ func AuthMiddleware(apiKeys []string, enabled bool) gin.HandlerFunc {
 		}
 
 		if apiKey == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "API key is required",
-			})
-			c.Abort()
+			problem(c, http.StatusUnauthorized, codeUnauthorized, "API key is required")
 			return
 		}
 
@@ -53,10 +49,7 @@ func AuthMiddleware(apiKeys []string, enabled bool) gin.HandlerFunc {
 		}
 
 		if !valid {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid API key",
-			})
-			c.Abort()
+			problem(c, http.StatusUnauthorized, codeUnauthorized, "Invalid API key")
 			return
 		}
 
@@ -64,6 +57,40 @@ func AuthMiddleware(apiKeys []string, enabled bool) gin.HandlerFunc {
 	}
 }
 
+// IDNMiddleware converts Unicode zone names in the path to their punycode
+// A-label form, so handlers only see ASCII zone names
+func IDNMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for i, param := range c.Params {
+			if param.Key != "zone" {
+				continue
+			}
+			zone, err := knot.ToASCII(param.Value)
+			if err != nil {
+				invalidParam(c, "zone", err.Error())
+				return
+			}
+			c.Params[i].Value = zone
+		}
+		c.Next()
+	}
+}
+
+// MetricsMiddleware records request counts and latency by route
+func MetricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// Use the route pattern to keep the number of series bounded
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
 // LoggingMiddleware creates logging middleware
 func LoggingMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -122,10 +149,7 @@ func RateLimitMiddleware() gin.HandlerFunc {
 
 		// Check rate limit
 		if len(clients[clientIP]) >= maxRequests {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded",
-			})
-			c.Abort()
+			problem(c, http.StatusTooManyRequests, codeRateLimited, "Rate limit exceeded")
 			return
 		}
 
@@ -144,9 +168,7 @@ func ErrorHandlingMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 			"path":   c.Request.URL.Path,
 		}).Error("Panic recovered")
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
-		})
+		problem(c, http.StatusInternalServerError, codeInternal, "Internal server error")
 	})
 }
 
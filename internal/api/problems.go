@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/hypr-technologies/hyprknot/internal/knot"
+)
+
+// problemContentType is the media type of RFC 7807 problem details
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Code is stable across
+// releases and meant for clients to switch on; Detail is for humans.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a validation failure of a single request field
+type FieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// Codes of problems raised by the API layer itself
+const (
+	codeInvalidRequest = "invalid_request"
+	codeValidation     = "validation_failed"
+	codeUnauthorized   = "unauthorized"
+	codeNotConfigured  = "auth_not_configured"
+	codeRateLimited    = "rate_limited"
+	codeMaintenance    = "zone_in_maintenance"
+	codeTimeout        = "timeout"
+	codeCanceled       = "request_canceled"
+	codeInternal       = "internal_error"
+)
+
+// statusClientClosedRequest reports requests the client gave up on before
+// the answer was ready, as nginx does; net/http has no name for it
+const statusClientClosedRequest = 499
+
+// problem aborts the request with a problem details response
+func problem(c *gin.Context, status int, code, detail string, fieldErrors ...FieldError) {
+	title := http.StatusText(status)
+	if status == statusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, Problem{
+		Type:      "about:blank",
+		Title:     title,
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		Code:      code,
+		RequestID: c.GetString("request_id"),
+		Errors:    fieldErrors,
+	})
+}
+
+// invalidParam responds with a validation problem for a single field
+func invalidParam(c *gin.Context, field, detail string) {
+	problem(c, http.StatusBadRequest, codeValidation, detail, FieldError{Field: field, Detail: detail})
+}
+
+// invalidBody responds to a request body that could not be bound, listing
+// the offending fields where the binding error names them
+func invalidBody(c *gin.Context, err error) {
+	var fieldErrors []FieldError
+
+	var verrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &verrs):
+		for _, verr := range verrs {
+			fieldErrors = append(fieldErrors, FieldError{
+				Field:  jsonFieldName(verr.Namespace()),
+				Detail: "failed on the '" + verr.Tag() + "' rule",
+			})
+		}
+	case errors.As(err, &typeErr):
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:  typeErr.Field,
+			Detail: "must be of type " + typeErr.Type.String(),
+		})
+	}
+
+	problem(c, http.StatusBadRequest, codeInvalidRequest, "Invalid request body", fieldErrors...)
+}
+
+// registerFieldNames makes gin's validator name fields by their json tags,
+// so that validation errors name the fields clients actually send
+var registerFieldNames = sync.OnceFunc(func() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+})
+
+// jsonFieldName turns a validator namespace such as
+// ChangesRequest.changes[0].name into changes[0].name
+func jsonFieldName(namespace string) string {
+	if _, field, ok := strings.Cut(namespace, "."); ok {
+		return field
+	}
+	return namespace
+}
+
+// respondError maps an error returned by the backend to a problem response.
+// Internal failures are reported with the given detail only, so that knotd
+// internals do not leak to clients.
+func (h *Handler) respondError(c *gin.Context, err error, detail string) {
+	var kerr *knot.Error
+	switch {
+	case errors.As(err, &kerr):
+		var fieldErrors []FieldError
+		if kerr.Field != "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: kerr.Field, Detail: err.Error()})
+		}
+		if knot.IsBackendUnavailable(err) {
+			problem(c, statusForKind(kerr.Kind), kerr.Code, "KnotDNS is not accessible")
+			return
+		}
+		problem(c, statusForKind(kerr.Kind), kerr.Code, err.Error(), fieldErrors...)
+	case errors.Is(err, context.DeadlineExceeded):
+		problem(c, http.StatusGatewayTimeout, codeTimeout, "KnotDNS did not answer in time")
+	case errors.Is(err, context.Canceled):
+		problem(c, statusClientClosedRequest, codeCanceled, "Request canceled before KnotDNS answered")
+	default:
+		problem(c, http.StatusInternalServerError, codeInternal, detail)
+	}
+}
+
+// statusForKind returns the HTTP status for a kind of backend error
+func statusForKind(kind error) int {
+	switch kind {
+	case knot.ErrNotAllowed:
+		return http.StatusForbidden
+	case knot.ErrNotFound:
+		return http.StatusNotFound
+	case knot.ErrConflict:
+		return http.StatusConflict
+	case knot.ErrInvalid:
+		return http.StatusBadRequest
+	case knot.ErrUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/hypr-technologies/hyprknot/internal/config"
 	"github.com/hypr-technologies/hyprknot/internal/knot"
+	"github.com/hypr-technologies/hyprknot/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
 // SetupRoutes sets up all API routes
-func SetupRoutes(cfg *config.Config, knotClient *knot.Client, logger *logrus.Logger) *gin.Engine {
+func SetupRoutes(cfg *config.Config, backend knot.Backend, m *metrics.Metrics, logger *logrus.Logger) *gin.Engine {
 	// Set Gin mode based on log level
 	if cfg.Log.Level == "debug" {
 		gin.SetMode(gin.DebugMode)
@@ -17,13 +18,15 @@ func SetupRoutes(cfg *config.Config, knotClient *knot.Client, logger *logrus.Log
 	}
 
 	router := gin.New()
+	registerFieldNames()
 
 	// Create handler
-	handler := NewHandler(knotClient, logger)
+	handler := NewHandler(backend, m, logger)
 
 	// Global middleware
 	router.Use(ErrorHandlingMiddleware(logger))
 	router.Use(LoggingMiddleware(logger))
+	router.Use(MetricsMiddleware(m))
 	router.Use(SecurityHeadersMiddleware())
 	router.Use(CORSMiddleware())
 	router.Use(RequestIDMiddleware())
@@ -32,20 +35,63 @@ func SetupRoutes(cfg *config.Config, knotClient *knot.Client, logger *logrus.Log
 	// Health check endpoint (no auth required)
 	router.GET("/health", handler.HealthCheck)
 
+	// Prometheus metrics, authenticated like the API
+	router.GET("/metrics", AuthMiddleware(cfg.Auth.APIKeys, cfg.Auth.Enabled), handler.Metrics)
+
 	// API routes with authentication
 	api := router.Group("/api/v1")
 	api.Use(AuthMiddleware(cfg.Auth.APIKeys, cfg.Auth.Enabled))
+	api.Use(IDNMiddleware())
+
+	// Changes to zones in maintenance are rejected. Freezing, thawing and
+	// toggling maintenance stay open so that maintenance can be ended.
+	writes := handler.RejectInMaintenance
 
 	// Zone routes
 	api.GET("/zones", handler.GetZones)
-	api.POST("/zones/:zone/reload", handler.ReloadZone)
+	api.POST("/zones", handler.CreateZone)
+	api.DELETE("/zones/:zone", writes, handler.DeleteZone)
+	api.GET("/zones/:zone/status", handler.GetZoneStatus)
+	api.POST("/zones/:zone/reload", writes, handler.ReloadZone)
+	api.POST("/zones/:zone/changes", writes, handler.ApplyChanges)
+	api.GET("/zones/:zone/export", handler.ExportZone)
+	api.POST("/zones/:zone/import", writes, handler.ImportZone)
+	api.POST("/zones/:zone/sync/plan", handler.PlanSync)
+	api.PUT("/zones/:zone/sync", writes, handler.ApplySync)
+	api.GET("/zones/:zone/soa", handler.GetSOA)
+	api.PUT("/zones/:zone/soa", writes, handler.UpdateSOA)
+
+	// Zone maintenance routes
+	api.POST("/zones/:zone/freeze", handler.FreezeZone)
+	api.POST("/zones/:zone/thaw", handler.ThawZone)
+	api.POST("/zones/:zone/flush", writes, handler.FlushZone)
+	api.POST("/zones/:zone/purge", writes, handler.PurgeZone)
+	api.PUT("/zones/:zone/maintenance", handler.SetMaintenance)
+
+	// Secondary zone routes
+	api.POST("/zones/:zone/refresh", writes, handler.RefreshZone)
+	api.POST("/zones/:zone/retransfer", writes, handler.RetransferZone)
+	api.POST("/zones/:zone/notify", handler.NotifyZone)
+
+	// DNSSEC routes
+	api.POST("/zones/:zone/dnssec/sign", writes, handler.SignZone)
+	api.POST("/zones/:zone/dnssec/rollover/:key", writes, handler.RolloverKey)
+	api.POST("/zones/:zone/dnssec/ksk-submitted", writes, handler.SubmitKSK)
+	api.GET("/zones/:zone/dnssec/keys", handler.GetDNSSECRecords)
+	api.GET("/zones/:zone/ds", handler.GetDS)
 
 	// Record routes
 	api.GET("/zones/:zone/records", handler.GetRecords)
 	api.GET("/zones/:zone/records/:name/:type", handler.GetRecord)
-	api.POST("/zones/:zone/records", handler.CreateRecord)
-	api.PUT("/zones/:zone/records/:name/:type", handler.UpdateRecord)
-	api.DELETE("/zones/:zone/records/:name/:type", handler.DeleteRecord)
+	api.POST("/zones/:zone/records", writes, handler.CreateRecord)
+	api.PUT("/zones/:zone/records/:name/:type", writes, handler.UpdateRecord)
+	api.DELETE("/zones/:zone/records/:name/:type", writes, handler.DeleteRecord)
+
+	// RRset routes
+	api.GET("/zones/:zone/rrsets/:name/:type", handler.GetRRSet)
+	api.PUT("/zones/:zone/rrsets/:name/:type", writes, handler.ReplaceRRSet)
+	api.POST("/zones/:zone/rrsets/:name/:type/values", writes, handler.AddRRSetValue)
+	api.DELETE("/zones/:zone/rrsets/:name/:type/values", writes, handler.RemoveRRSetValue)
 
 	// API documentation endpoint
 	api.GET("/docs", func(c *gin.Context) {
@@ -58,23 +104,145 @@ func SetupRoutes(cfg *config.Config, knotClient *knot.Client, logger *logrus.Log
 					"path":   "/health",
 					"desc":   "Health check endpoint",
 				},
+				"metrics": map[string]string{
+					"method": "GET",
+					"path":   "/metrics",
+					"desc":   "Prometheus metrics for HTTP requests, control commands and knotd statistics",
+				},
 				"zones": map[string]interface{}{
 					"list": map[string]string{
 						"method": "GET",
+						"path":   "/api/v1/zones?status={true|false}",
+						"desc":   "List all zones, with status=true including their status",
+					},
+					"status": map[string]string{
+						"method": "GET",
+						"path":   "/api/v1/zones/{zone}/status",
+						"desc":   "Get role, serial, transaction, freeze state and scheduled events of a zone",
+					},
+					"create": map[string]string{
+						"method": "POST",
 						"path":   "/api/v1/zones",
-						"desc":   "List all zones",
+						"desc":   "Create a zone from the configured zone template",
+					},
+					"delete": map[string]string{
+						"method": "DELETE",
+						"path":   "/api/v1/zones/{zone}?purge={true|false}",
+						"desc":   "Remove a zone from the configuration, optionally purging its data",
 					},
 					"reload": map[string]string{
 						"method": "POST",
 						"path":   "/api/v1/zones/{zone}/reload",
 						"desc":   "Reload a zone",
 					},
+					"freeze": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/freeze",
+						"desc":   "Freeze a zone and put it into maintenance",
+					},
+					"thaw": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/thaw",
+						"desc":   "Thaw a zone and end its maintenance",
+					},
+					"flush": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/flush",
+						"desc":   "Write a zone to its zone file",
+					},
+					"purge": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/purge?confirm={zone}",
+						"desc":   "Remove zone file, journal, timers and keys while keeping the zone configured",
+					},
+					"refresh": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/refresh",
+						"desc":   "Check the primary of a secondary zone for updates",
+					},
+					"retransfer": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/retransfer",
+						"desc":   "Transfer a secondary zone in full from its primary",
+					},
+					"notify": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/notify",
+						"desc":   "Send NOTIFY for a zone to its remotes",
+					},
+					"maintenance": map[string]string{
+						"method": "PUT",
+						"path":   "/api/v1/zones/{zone}/maintenance",
+						"desc":   "Turn maintenance on or off; zones in maintenance reject changes with 423",
+					},
+					"changes": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/changes?dry_run={true|false}",
+						"desc":   "Apply add/remove/replace operations in one transaction",
+					},
+					"export": map[string]string{
+						"method": "GET",
+						"path":   "/api/v1/zones/{zone}/export?format={zone|json|yaml|csv}",
+						"desc":   "Export a zone as an RFC 1035 zone file, JSON, YAML or CSV, chosen by format or Accept",
+					},
+					"import": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/import?mode={merge|replace}&dry_run={true|false}",
+						"desc":   "Import a zone file in one transaction, merging it or making the zone match it",
+					},
+					"sync_plan": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/sync/plan",
+						"desc":   "Show the adds, removes and changes that would make a zone or subtree match the given RRsets",
+					},
+					"sync": map[string]string{
+						"method": "PUT",
+						"path":   "/api/v1/zones/{zone}/sync?dry_run={true|false}",
+						"desc":   "Make a zone or subtree match the given RRsets in one transaction, optionally only if the plan fingerprint still matches",
+					},
+					"get_soa": map[string]string{
+						"method": "GET",
+						"path":   "/api/v1/zones/{zone}/soa",
+						"desc":   "Get the SOA fields and serial policy of a zone",
+					},
+					"update_soa": map[string]string{
+						"method": "PUT",
+						"path":   "/api/v1/zones/{zone}/soa?dry_run={true|false}",
+						"desc":   "Update SOA fields, advancing the serial by the zone's serial policy",
+					},
+				},
+				"dnssec": map[string]interface{}{
+					"sign": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/dnssec/sign",
+						"desc":   "Re-sign a zone",
+					},
+					"rollover": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/dnssec/rollover/{ksk|zsk}",
+						"desc":   "Start a KSK or ZSK rollover",
+					},
+					"ksk_submitted": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/dnssec/ksk-submitted",
+						"desc":   "Confirm the new KSK's DS is published in the parent zone",
+					},
+					"keys": map[string]string{
+						"method": "GET",
+						"path":   "/api/v1/zones/{zone}/dnssec/keys",
+						"desc":   "List DNSKEY, CDS and CDNSKEY records",
+					},
+					"ds": map[string]string{
+						"method": "GET",
+						"path":   "/api/v1/zones/{zone}/ds?digest={sha256|sha384}",
+						"desc":   "Derive DS records for the parent zone",
+					},
 				},
 				"records": map[string]interface{}{
 					"list": map[string]string{
 						"method": "GET",
-						"path":   "/api/v1/zones/{zone}/records",
-						"desc":   "List all records in a zone",
+						"path":   "/api/v1/zones/{zone}/records?name={name}&type={type}",
+						"desc":   "List records in a zone, optionally filtered by name and type",
 					},
 					"get": map[string]string{
 						"method": "GET",
@@ -83,23 +251,46 @@ func SetupRoutes(cfg *config.Config, knotClient *knot.Client, logger *logrus.Log
 					},
 					"create": map[string]string{
 						"method": "POST",
-						"path":   "/api/v1/zones/{zone}/records",
+						"path":   "/api/v1/zones/{zone}/records?dry_run={true|false}",
 						"desc":   "Create a new record",
 					},
 					"update": map[string]string{
 						"method": "PUT",
-						"path":   "/api/v1/zones/{zone}/records/{name}/{type}",
-						"desc":   "Update an existing record",
+						"path":   "/api/v1/zones/{zone}/records/{name}/{type}?dry_run={true|false}",
+						"desc":   "Update an existing record, use match to pick one value of an RRset",
 					},
 					"delete": map[string]string{
 						"method": "DELETE",
-						"path":   "/api/v1/zones/{zone}/records/{name}/{type}",
-						"desc":   "Delete a record",
+						"path":   "/api/v1/zones/{zone}/records/{name}/{type}?dry_run={true|false}",
+						"desc":   "Delete all records of a type at a name, or one record with ?data={rdata}",
+					},
+				},
+				"rrsets": map[string]interface{}{
+					"get": map[string]string{
+						"method": "GET",
+						"path":   "/api/v1/zones/{zone}/rrsets/{name}/{type}",
+						"desc":   "Get all values of an RRset",
+					},
+					"replace": map[string]string{
+						"method": "PUT",
+						"path":   "/api/v1/zones/{zone}/rrsets/{name}/{type}?dry_run={true|false}",
+						"desc":   "Replace all values of an RRset",
+					},
+					"add_value": map[string]string{
+						"method": "POST",
+						"path":   "/api/v1/zones/{zone}/rrsets/{name}/{type}/values?dry_run={true|false}",
+						"desc":   "Add a value to an RRset",
+					},
+					"remove_value": map[string]string{
+						"method": "DELETE",
+						"path":   "/api/v1/zones/{zone}/rrsets/{name}/{type}/values?value={value}&dry_run={true|false}",
+						"desc":   "Remove a single value from an RRset",
 					},
 				},
 			},
 			"supported_record_types": []string{
 				"A", "AAAA", "PTR", "CNAME", "MX", "TXT", "NS",
+				"SRV", "CAA", "TLSA", "SSHFP", "DS", "SVCB", "HTTPS",
 			},
 			"authentication": map[string]interface{}{
 				"enabled": cfg.Auth.Enabled,
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/hypr-technologies/hyprknot/internal/knot"
 	"gopkg.in/yaml.v3"
 )
 
@@ -27,11 +29,38 @@ type ServerConfig struct {
 
 // KnotConfig contains KnotDNS configuration
 type KnotConfig struct {
-	ConfigPath   string   `yaml:"config_path"`
-	SocketPath   string   `yaml:"socket_path"`
-	KnotcPath    string   `yaml:"knotc_path"`
-	AllowedZones []string `yaml:"allowed_zones"`
-	DataDir      string   `yaml:"data_dir"`
+	Backend      string             `yaml:"backend"`   // "knot" (default) or "memory"
+	Transport    string             `yaml:"transport"` // "socket" (default) or "knotc"
+	ConfigPath   string             `yaml:"config_path"`
+	SocketPath   string             `yaml:"socket_path"`
+	KnotcPath    string             `yaml:"knotc_path"`
+	AllowedZones []string           `yaml:"allowed_zones"`
+	MemoryZones  []string           `yaml:"memory_zones"` // Zones served by the memory backend
+	DataDir      string             `yaml:"data_dir"`
+	ZoneTemplate ZoneTemplateConfig `yaml:"zone_template"`
+
+	// Serial policy for SOA edits: "increment" (default), "unixtime" or
+	// "dateserial", optionally overridden per zone
+	SerialPolicy   string            `yaml:"serial_policy"`
+	SerialPolicies map[string]string `yaml:"serial_policies"`
+
+	// Timeout in seconds for every control command sent to knotd, optionally
+	// overridden per command, e.g. zone-commit; 0 disables the timeout
+	CommandTimeout  int            `yaml:"command_timeout"`
+	CommandTimeouts map[string]int `yaml:"command_timeouts"`
+}
+
+// ZoneTemplateConfig describes zones created through the API
+type ZoneTemplateConfig struct {
+	KnotTemplate string   `yaml:"knot_template"` // knot.conf template assigned to new zones
+	TTL          uint32   `yaml:"ttl"`
+	MName        string   `yaml:"soa_mname"`
+	RName        string   `yaml:"soa_rname"`
+	Refresh      uint32   `yaml:"soa_refresh"`
+	Retry        uint32   `yaml:"soa_retry"`
+	Expire       uint32   `yaml:"soa_expire"`
+	Minimum      uint32   `yaml:"soa_minimum"`
+	Nameservers  []string `yaml:"nameservers"`
 }
 
 // AuthConfig contains authentication configuration
@@ -58,11 +87,23 @@ func DefaultConfig() *Config {
 			IdleTimeout:  120,
 		},
 		Knot: KnotConfig{
+			Backend:      "knot",
+			Transport:    "socket",
 			ConfigPath:   "/etc/knot/knot.conf",
 			SocketPath:   "/run/knot/knot.sock",
 			KnotcPath:    "/usr/sbin/knotc", // Default for Debian/Ubuntu
 			AllowedZones: []string{},
 			DataDir:      "/var/lib/knot",
+			SerialPolicy: "increment",
+			ZoneTemplate: ZoneTemplateConfig{
+				TTL:         3600,
+				Refresh:     3600,
+				Retry:       900,
+				Expire:      604800,
+				Minimum:     300,
+				Nameservers: []string{},
+			},
+			CommandTimeout: 10,
 		},
 		Auth: AuthConfig{
 			Enabled: true,
@@ -116,13 +157,37 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate knot config
-	if c.Knot.KnotcPath == "" {
-		return fmt.Errorf("knotc_path cannot be empty")
+	switch c.Knot.Backend {
+	case "knot":
+		if err := c.validateTransport(); err != nil {
+			return err
+		}
+	case "memory":
+		if len(c.Knot.MemoryZones) == 0 && len(c.Knot.AllowedZones) == 0 {
+			return fmt.Errorf("memory backend requires memory_zones or allowed_zones")
+		}
+	default:
+		return fmt.Errorf("invalid knot backend: %s", c.Knot.Backend)
+	}
+
+	// Validate serial policies
+	if !knot.IsValidSerialPolicy(c.Knot.SerialPolicy) {
+		return fmt.Errorf("invalid serial policy: %s", c.Knot.SerialPolicy)
+	}
+	for zone, policy := range c.Knot.SerialPolicies {
+		if !knot.IsValidSerialPolicy(policy) {
+			return fmt.Errorf("invalid serial policy for zone %s: %s", zone, policy)
+		}
 	}
 
-	// Check if knotc exists
-	if _, err := os.Stat(c.Knot.KnotcPath); os.IsNotExist(err) {
-		return fmt.Errorf("knotc binary not found at: %s", c.Knot.KnotcPath)
+	// Validate command timeouts
+	if c.Knot.CommandTimeout < 0 {
+		return fmt.Errorf("command timeout cannot be negative")
+	}
+	for cmd, timeout := range c.Knot.CommandTimeouts {
+		if timeout < 0 {
+			return fmt.Errorf("command timeout for %s cannot be negative", cmd)
+		}
 	}
 
 	// Validate log level
@@ -136,6 +201,38 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// validateTransport validates the settings of the configured knotd transport
+func (c *Config) validateTransport() error {
+	switch c.Knot.Transport {
+	case "socket":
+		if c.Knot.SocketPath == "" {
+			return fmt.Errorf("socket_path cannot be empty when using the socket transport")
+		}
+	case "knotc":
+		if c.Knot.KnotcPath == "" {
+			return fmt.Errorf("knotc_path cannot be empty")
+		}
+
+		// Check if knotc exists
+		if _, err := os.Stat(c.Knot.KnotcPath); os.IsNotExist(err) {
+			return fmt.Errorf("knotc binary not found at: %s", c.Knot.KnotcPath)
+		}
+	default:
+		return fmt.Errorf("invalid knot transport: %s", c.Knot.Transport)
+	}
+
+	return nil
+}
+
+// GetMemoryZones returns the zones served by the memory backend, falling
+// back to the allowed zones when none are configured explicitly
+func (c *Config) GetMemoryZones() []string {
+	if len(c.Knot.MemoryZones) > 0 {
+		return c.Knot.MemoryZones
+	}
+	return c.Knot.AllowedZones
+}
+
 // SaveConfig saves configuration to file
 func (c *Config) SaveConfig(configPath string) error {
 	// Create directory if it doesn't exist
@@ -158,6 +255,15 @@ func (c *Config) SaveConfig(configPath string) error {
 	return nil
 }
 
+// GetCommandTimeouts returns the per-command timeouts as durations
+func (c *Config) GetCommandTimeouts() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration, len(c.Knot.CommandTimeouts))
+	for cmd, seconds := range c.Knot.CommandTimeouts {
+		timeouts[cmd] = time.Duration(seconds) * time.Second
+	}
+	return timeouts
+}
+
 // GetAddress returns the server address
 func (c *Config) GetAddress() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
@@ -0,0 +1,53 @@
+package knot
+
+import (
+	"context"
+	"io"
+)
+
+// Backend is the set of zone operations the API is built on. It is
+// implemented by Client, which talks to knotd or to an in-memory store.
+// Methods that reach knotd give up once ctx is done.
+type Backend interface {
+	GetZones(ctx context.Context) ([]string, error)
+	GetZoneStatus(ctx context.Context, zone string) (*ZoneStatus, error)
+	GetZoneStatuses(ctx context.Context) ([]ZoneStatus, error)
+	CreateZone(ctx context.Context, zone string) error
+	DeleteZone(ctx context.Context, zone string, purge bool) error
+	GetRecords(ctx context.Context, zone string) ([]DNSRecord, error)
+	ExportZone(ctx context.Context, zone string) (*Zone, error)
+	ImportZone(ctx context.Context, zone string, zoneFile io.Reader, mode string) (*ImportResult, error)
+	PlanSync(ctx context.Context, zone string, req *SyncRequest) (*SyncPlan, error)
+	ApplySync(ctx context.Context, zone string, req *SyncRequest) (*SyncPlan, error)
+	FindRecords(ctx context.Context, zone, name string, recordType RecordType) ([]DNSRecord, error)
+	GetRecord(ctx context.Context, zone, name string, recordType RecordType) (*DNSRecord, error)
+	CreateRecord(ctx context.Context, zone string, record *DNSRecord) error
+	UpdateRecord(ctx context.Context, zone, name string, recordType RecordType, updates *UpdateRecordRequest) error
+	DeleteRecord(ctx context.Context, zone, name string, recordType RecordType) error
+	GetRRSet(ctx context.Context, zone, name string, recordType RecordType) (*RRSet, error)
+	ReplaceRRSet(ctx context.Context, zone string, rrset *RRSet) error
+	AddRRSetValue(ctx context.Context, zone, name string, recordType RecordType, ttl uint32, value string) error
+	RemoveRRSetValue(ctx context.Context, zone, name string, recordType RecordType, value string) error
+	ApplyChanges(ctx context.Context, zone string, changes []Change) error
+	GetSOA(ctx context.Context, zone string) (*SOA, error)
+	UpdateSOA(ctx context.Context, zone string, updates *UpdateSOARequest) (*SOA, error)
+	SignZone(ctx context.Context, zone string) error
+	RolloverKey(ctx context.Context, zone, keyType string) error
+	SubmitKSK(ctx context.Context, zone string) error
+	GetDNSSECRecords(ctx context.Context, zone string) (*DNSSECRecords, error)
+	GetDS(ctx context.Context, zone string, digestType uint8) ([]DSRecord, error)
+	ReloadZone(ctx context.Context, zone string) error
+	FreezeZone(ctx context.Context, zone string) error
+	ThawZone(ctx context.Context, zone string) error
+	FlushZone(ctx context.Context, zone string) error
+	RefreshZone(ctx context.Context, zone string) error
+	RetransferZone(ctx context.Context, zone string) error
+	NotifyZone(ctx context.Context, zone string) error
+	PurgeZone(ctx context.Context, zone string) error
+	SetMaintenance(zone string, enabled bool) error
+	InMaintenance(ctx context.Context, zone string) (bool, error)
+	GetStats(ctx context.Context) ([]Stat, error)
+	CheckHealth(ctx context.Context) error
+}
+
+var _ Backend = (*Client)(nil)
@@ -1,32 +1,110 @@
 package knot
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/hypr-technologies/hyprknot/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
+// Transports supported for talking to knotd
+const (
+	TransportSocket = "socket" // native control protocol over socket_path
+	TransportKnotc  = "knotc"  // fork knotc for every command
+)
+
+// healthCheckTimeout bounds the connection used by CheckHealth
+const healthCheckTimeout = 5 * time.Second
+
+// Retry policy for zone-begin when another transaction is open
+const (
+	txnBeginAttempts = 5
+	txnBeginBackoff  = 100 * time.Millisecond
+)
+
+// Options configures a KnotDNS client
+type Options struct {
+	Transport    string
+	KnotcPath    string
+	SocketPath   string
+	AllowedZones []string
+	ZoneTemplate ZoneTemplate
+
+	// SerialPolicy is applied to SOA edits of zones not listed in
+	// SerialPolicies; empty means increment
+	SerialPolicy   string
+	SerialPolicies map[string]string
+
+	// Metrics records control commands and aborted transactions, optional
+	Metrics *metrics.Metrics
+
+	// CommandTimeout bounds every control command unless CommandTimeouts,
+	// keyed by command such as "zone-commit", sets another one. Zero means
+	// no timeout.
+	CommandTimeout  time.Duration
+	CommandTimeouts map[string]time.Duration
+}
+
 // Client represents a KnotDNS client
 type Client struct {
-	knotcPath    string
-	socketPath   string
 	allowedZones []string
+	zoneTemplate ZoneTemplate
+	dial         func(ctx context.Context) (conn, error)
 	logger       *logrus.Logger
+	metrics      *metrics.Metrics
+
+	commandTimeout  time.Duration
+	commandTimeouts map[string]time.Duration
+
+	defaultSerialPolicy string
+	serialPolicies      map[string]string
+
+	locksMu   sync.Mutex
+	zoneLocks map[string]chan struct{} // full while the zone is locked
+	confMu    sync.Mutex               // knotd allows a single configuration transaction
+
+	maintenanceMu sync.RWMutex
+	maintenance   map[string]bool // zones that reject changes
 }
 
 // NewClient creates a new KnotDNS client
-func NewClient(knotcPath, socketPath string, allowedZones []string, logger *logrus.Logger) *Client {
+func NewClient(opts Options, logger *logrus.Logger) *Client {
+	var dial func(ctx context.Context) (conn, error)
+	switch opts.Transport {
+	case TransportKnotc:
+		dial = func(ctx context.Context) (conn, error) {
+			return &knotcConn{knotcPath: opts.KnotcPath, socketPath: opts.SocketPath}, nil
+		}
+	default:
+		dial = func(ctx context.Context) (conn, error) {
+			return dialSocket(ctx, opts.SocketPath)
+		}
+	}
+
+	return newClient(opts, dial, logger)
+}
+
+// newClient creates a client on top of the given transport
+func newClient(opts Options, dial func(ctx context.Context) (conn, error), logger *logrus.Logger) *Client {
 	return &Client{
-		knotcPath:    knotcPath,
-		socketPath:   socketPath,
-		allowedZones: allowedZones,
+		allowedZones: opts.AllowedZones,
+		zoneTemplate: opts.ZoneTemplate,
+		dial:         dial,
 		logger:       logger,
+		metrics:      opts.Metrics,
+		zoneLocks:    make(map[string]chan struct{}),
+		maintenance:  make(map[string]bool),
+
+		defaultSerialPolicy: opts.SerialPolicy,
+		serialPolicies:      opts.SerialPolicies,
+		commandTimeout:      opts.CommandTimeout,
+		commandTimeouts:     opts.CommandTimeouts,
 	}
 }
 
@@ -38,6 +116,28 @@ func normalizeZoneName(zone string) string {
 	return zone
 }
 
+// lockZone serializes mutations of a zone within this process while leaving
+// other zones untouched. It returns the matching unlock function, or the
+// error of ctx if it is done before the lock is free.
+func (c *Client) lockZone(ctx context.Context, zone string) (func(), error) {
+	key := strings.ToLower(normalizeZoneName(zone))
+
+	c.locksMu.Lock()
+	lock, ok := c.zoneLocks[key]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		c.zoneLocks[key] = lock
+	}
+	c.locksMu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for another change to zone %s: %w", zone, ctx.Err())
+	}
+}
+
 // IsZoneAllowed checks if a zone is in the allowed zones list
 func (c *Client) IsZoneAllowed(zone string) bool {
 	if len(c.allowedZones) == 0 {
@@ -56,343 +156,823 @@ func (c *Client) IsZoneAllowed(zone string) bool {
 	return false
 }
 
-// executeKnotc executes a knotc command
-func (c *Client) executeKnotc(args ...string) (string, error) {
-	cmdArgs := []string{}
-	if c.socketPath != "" {
-		cmdArgs = append(cmdArgs, "-s", c.socketPath)
+// withConn opens a control connection, runs fn and closes the connection
+func (c *Client) withConn(ctx context.Context, fn func(conn) error) error {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return unavailable(err)
+	}
+	defer cn.close()
+
+	return fn(cn)
+}
+
+// timeoutFor returns the timeout configured for a control command
+func (c *Client) timeoutFor(cmd string) time.Duration {
+	if timeout, ok := c.commandTimeouts[cmd]; ok {
+		return timeout
 	}
-	cmdArgs = append(cmdArgs, args...)
+	return c.commandTimeout
+}
 
-	c.logger.Debugf("Executing knotc command: %s %v", c.knotcPath, cmdArgs)
+// execute sends a control command over cn and passes every response data
+// unit to fn
+func (c *Client) execute(ctx context.Context, cn conn, req ctlData, fn func(ctlData) error) error {
+	c.logger.Debugf("Executing control command: %s %v", req[ctlIdxCmd], req[ctlIdxCmd+1:])
 
-	cmd := exec.Command(c.knotcPath, cmdArgs...)
-	output, err := cmd.CombinedOutput()
+	if timeout := c.timeoutFor(req[ctlIdxCmd]); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
+	start := time.Now()
+	err := cn.exec(ctx, req, fn)
+	c.metrics.ObserveCommand(req[ctlIdxCmd], time.Since(start), err)
 	if err != nil {
-		c.logger.Errorf("knotc command failed: %v, output: %s", err, string(output))
-		return "", fmt.Errorf("knotc command failed: %w, output: %s", err, string(output))
+		c.logger.Errorf("Control command %s failed: %v", req[ctlIdxCmd], err)
+		return classifyError(err)
 	}
 
-	result := strings.TrimSpace(string(output))
-	c.logger.Debugf("knotc command output: %s", result)
+	return nil
+}
 
-	return result, nil
+// command executes a single control command on its own connection
+func (c *Client) command(ctx context.Context, req ctlData, fn func(ctlData) error) error {
+	return c.withConn(ctx, func(cn conn) error {
+		return c.execute(ctx, cn, req, fn)
+	})
 }
 
-// GetZones returns a list of configured zones
-func (c *Client) GetZones() ([]string, error) {
-	output, err := c.executeKnotc("conf-read", "zone")
+// txn is an open zone transaction bound to a single control connection and
+// to the context of the request that opened it
+type txn struct {
+	ctx    context.Context
+	client *Client
+	conn   conn
+	zone   string
+}
+
+// set adds a record to the transaction (zone-set)
+func (t *txn) set(owner string, ttl uint32, recordType RecordType, rdata string) error {
+	return t.client.execute(t.ctx, t.conn, ctlData{
+		ctlIdxCmd:   "zone-set",
+		ctlIdxZone:  t.zone,
+		ctlIdxOwner: owner,
+		ctlIdxTTL:   strconv.FormatUint(uint64(ttl), 10),
+		ctlIdxType:  string(recordType),
+		ctlIdxData:  rdata,
+	}, nil)
+}
+
+// unset removes records from the transaction (zone-unset). An empty rdata
+// removes the whole RRset of the given type.
+func (t *txn) unset(owner string, recordType RecordType, rdata string) error {
+	return t.client.execute(t.ctx, t.conn, ctlData{
+		ctlIdxCmd:   "zone-unset",
+		ctlIdxZone:  t.zone,
+		ctlIdxOwner: owner,
+		ctlIdxType:  string(recordType),
+		ctlIdxData:  rdata,
+	}, nil)
+}
+
+// get returns the TTL and values of an RRset as seen inside the open
+// transaction (zone-get), or no values if the RRset does not exist
+func (t *txn) get(owner string, recordType RecordType) (uint32, []string, error) {
+	var ttl uint32
+	var values []string
+
+	err := t.client.execute(t.ctx, t.conn, ctlData{
+		ctlIdxCmd:   "zone-get",
+		ctlIdxZone:  t.zone,
+		ctlIdxOwner: owner,
+		ctlIdxType:  string(recordType),
+	}, func(data ctlData) error {
+		record, err := recordFromCtl(data)
+		if err != nil {
+			return err
+		}
+		ttl = record.TTL
+		values = append(values, record.RData())
+		return nil
+	})
+	if err != nil && !isNotFoundError(err) {
+		return 0, nil, err
+	}
+
+	return ttl, values, nil
+}
+
+// classifyError attaches a kind to failures reported by knotd, so that
+// they can be told apart without matching messages further up
+func classifyError(err error) error {
+	var kerr *Error
+	if errors.As(err, &kerr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "no such zone"):
+		return &Error{Kind: ErrNotFound, Code: "zone_not_found", Err: err}
+	case isNotFoundError(err):
+		return &Error{Kind: ErrNotFound, Code: "record_not_found", Err: err}
+	case strings.Contains(msg, "not supported"):
+		return &Error{Kind: ErrConflict, Code: "not_supported", Err: err}
+	case strings.Contains(msg, "malformed data"):
+		return &Error{Kind: ErrInvalid, Code: "validation_failed", Err: err}
+	}
+	return err
+}
+
+// isNotFoundError reports whether a control error means that the requested
+// node or RRset does not exist
+func isNotFoundError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "no such node") || strings.Contains(msg, "no such record") ||
+		strings.Contains(msg, "not exists")
+}
+
+// transaction runs fn inside a zone-begin/zone-commit pair on one control
+// connection and aborts the transaction if anything fails, including
+// cancellation of ctx
+func (c *Client) transaction(ctx context.Context, zone string, fn func(*txn) error) error {
+	// Use normalized zone name for KnotDNS commands
+	normalizedZone := normalizeZoneName(zone)
+
+	// Changes to secondaries would be overwritten by the next transfer
+	if err := c.requirePrimary(ctx, normalizedZone); err != nil {
+		return err
+	}
+
+	return c.withConn(ctx, func(cn conn) error {
+		t := &txn{ctx: ctx, client: c, conn: cn, zone: normalizedZone}
+
+		// Begin transaction
+		if err := c.begin(ctx, cn, normalizedZone); err != nil {
+			return fmt.Errorf("failed to begin transaction for zone %s: %w", zone, err)
+		}
+
+		if err := fn(t); err != nil {
+			c.abort(ctx, cn, normalizedZone)
+			return err
+		}
+
+		// A dry run reports what the commit would do and throws it away
+		if dry := dryRunFrom(ctx); dry != nil {
+			err := c.dryRun(ctx, cn, normalizedZone, dry)
+			if abortErr := c.cleanup(ctx, cn, ctlData{ctlIdxCmd: "zone-abort", ctlIdxZone: normalizedZone}); abortErr != nil {
+				c.logger.Warnf("Failed to abort dry run transaction for zone %s: %v", zone, abortErr)
+			}
+			return err
+		}
+
+		// Commit transaction
+		if err := c.execute(ctx, cn, ctlData{ctlIdxCmd: "zone-commit", ctlIdxZone: normalizedZone}, nil); err != nil {
+			c.abort(ctx, cn, normalizedZone)
+			return fmt.Errorf("failed to commit transaction for zone %s: %w", zone, err)
+		}
+
+		return nil
+	})
+}
+
+// begin opens a zone transaction. A transaction left open by someone else
+// is retried with exponential backoff before giving up with
+// ErrTransactionBusy, or with the error of ctx if it ends first. A
+// zone-begin cut off by ctx or its timeout is followed by a zone-abort.
+func (c *Client) begin(ctx context.Context, cn conn, zone string) error {
+	backoff := txnBeginBackoff
+	for attempt := 1; ; attempt++ {
+		err := c.execute(ctx, cn, ctlData{ctlIdxCmd: "zone-begin", ctlIdxZone: zone}, nil)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			// knotd may have opened the transaction before the command was
+			// cut off, and it would outlive the connection
+			c.abort(ctx, cn, zone)
+			return err
+		}
+		if !isTxnBusyError(err) {
+			return err
+		}
+		if attempt == txnBeginAttempts {
+			return fmt.Errorf("%w: %v", ErrTransactionBusy, err)
+		}
+
+		c.logger.Warnf("Zone %s has an open transaction, retrying in %s", zone, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isTxnBusyError reports whether a control error means that the zone
+// already has an open transaction
+func isTxnBusyError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "too many transactions") || strings.Contains(msg, "transaction already")
+}
+
+// abort aborts an open transaction, logging but otherwise ignoring failures
+func (c *Client) abort(ctx context.Context, cn conn, zone string) {
+	c.metrics.TransactionAborted(zone)
+	if err := c.cleanup(ctx, cn, ctlData{ctlIdxCmd: "zone-abort", ctlIdxZone: zone}); err != nil {
+		c.logger.Warnf("Failed to abort transaction for zone %s: %v", zone, err)
+	}
+}
+
+// cleanup runs a command that closes a transaction. knotd keeps
+// transactions open after the request is gone, so the command runs even if
+// ctx was cancelled, on a new connection when cn was interrupted.
+func (c *Client) cleanup(ctx context.Context, cn conn, req ctlData) error {
+	if ctx.Err() == nil {
+		err := c.execute(ctx, cn, req, nil)
+		if !errors.Is(err, errInterrupted) {
+			return err
+		}
+	}
+
+	// knotd serves one control session at a time, so the interrupted one
+	// has to end before the new one gets through
+	cn.close()
+
+	ctx = context.WithoutCancel(ctx)
+	fresh, err := c.dial(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get zones: %w", err)
+		return unavailable(err)
+	}
+	defer fresh.close()
+	return c.execute(ctx, fresh, req, nil)
+}
+
+// readZone streams the records of a zone to fn (zone-read). A non-empty
+// owner restricts the read to that node, and with it the record type, so
+// that point lookups do not transfer the whole zone.
+func (c *Client) readZone(ctx context.Context, zone, owner string, recordType RecordType, fn func(*DNSRecord) error) error {
+	req := ctlData{ctlIdxCmd: "zone-read", ctlIdxZone: zone}
+	if owner != "" {
+		req[ctlIdxOwner] = owner
+		req[ctlIdxType] = string(recordType)
 	}
 
+	err := c.command(ctx, req, func(data ctlData) error {
+		record, err := recordFromCtl(data)
+		if err != nil {
+			c.logger.Warnf("Failed to parse record: %v, error: %v", data[ctlIdxOwner:ctlIdxCount], err)
+			return nil
+		}
+		// Without an owner the type cannot be pushed down to every transport
+		if recordType != "" && record.Type != recordType {
+			return nil
+		}
+		return fn(record)
+	})
+
+	// A missing node is an empty result rather than a failure
+	if err != nil && owner != "" && isNotFoundError(err) {
+		return nil
+	}
+	return err
+}
+
+// GetZones returns a list of configured zones
+func (c *Client) GetZones(ctx context.Context) ([]string, error) {
 	var zones []string
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if strings.HasPrefix(line, "zone[") {
-			// Extract zone name from zone[example.com]
-			start := strings.Index(line, "[")
-			end := strings.Index(line, "]")
-			if start != -1 && end != -1 && end > start {
-				zoneName := line[start+1 : end]
-				if c.IsZoneAllowed(zoneName) {
-					zones = append(zones, zoneName)
-				}
-			}
+	seen := make(map[string]bool)
+
+	err := c.command(ctx, ctlData{ctlIdxCmd: "conf-read", ctlIdxSection: "zone"}, func(data ctlData) error {
+		// Every zone item is reported with the zone name as its identifier
+		zoneName := data[ctlIdxID]
+		if data[ctlIdxSection] != "zone" || zoneName == "" || seen[zoneName] {
+			return nil
+		}
+		seen[zoneName] = true
+		if c.IsZoneAllowed(zoneName) {
+			zones = append(zones, zoneName)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zones: %w", err)
 	}
 
 	return zones, nil
 }
 
 // GetRecords returns all records for a zone
-func (c *Client) GetRecords(zone string) ([]DNSRecord, error) {
+func (c *Client) GetRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	return c.FindRecords(ctx, zone, "", "")
+}
+
+// FindRecords returns the records of a zone matching a name and type. An
+// empty name or type matches everything.
+func (c *Client) FindRecords(ctx context.Context, zone, name string, recordType RecordType) ([]DNSRecord, error) {
 	if !c.IsZoneAllowed(zone) {
-		return nil, fmt.Errorf("zone not allowed: %s", zone)
+		return nil, fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
 	}
 
 	// Use normalized zone name for KnotDNS commands
 	normalizedZone := normalizeZoneName(zone)
-	output, err := c.executeKnotc("zone-read", normalizedZone)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read zone %s: %w", zone, err)
-	}
 
-	var records []DNSRecord
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, ";") {
-			continue
-		}
-
-		record, err := ParseKnotRecord(line)
-		if err != nil {
-			c.logger.Warnf("Failed to parse record: %s, error: %v", line, err)
-			continue
+	owner := ""
+	if name != "" {
+		var err error
+		if owner, err = ownerName(zone, name); err != nil {
+			return nil, err
 		}
+	}
 
+	var records []DNSRecord
+	err := c.readZone(ctx, normalizedZone, owner, recordType, func(record *DNSRecord) error {
+		qualifyRecord(normalizedZone, record)
 		records = append(records, *record)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zone %s: %w", zone, err)
 	}
 
 	return records, nil
 }
 
 // GetRecord returns a specific record
-func (c *Client) GetRecord(zone, name string, recordType RecordType) (*DNSRecord, error) {
-	if !c.IsZoneAllowed(zone) {
-		return nil, fmt.Errorf("zone not allowed: %s", zone)
+func (c *Client) GetRecord(ctx context.Context, zone, name string, recordType RecordType) (*DNSRecord, error) {
+	records, err := c.FindRecords(ctx, zone, name, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: %s %s in zone %s", ErrRecordNotFound, name, recordType, zone)
 	}
 
-	records, err := c.GetRecords(zone)
+	return &records[0], nil
+}
+
+// GetRRSet returns all values of the RRset with the given name and type
+func (c *Client) GetRRSet(ctx context.Context, zone, name string, recordType RecordType) (*RRSet, error) {
+	records, err := c.FindRecords(ctx, zone, name, recordType)
 	if err != nil {
 		return nil, err
 	}
 
-	// Normalize the search name for comparison
-	searchName := name
-	if !strings.HasSuffix(searchName, ".") {
-		searchName += "."
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: %s %s in zone %s", ErrRecordNotFound, name, recordType, zone)
 	}
 
+	rrset := &RRSet{
+		Name:        records[0].Name,
+		FQDN:        records[0].FQDN,
+		NameUnicode: records[0].NameUnicode,
+		FQDNUnicode: records[0].FQDNUnicode,
+		Type:        recordType,
+		TTL:         records[0].TTL,
+	}
 	for _, record := range records {
-		// Normalize the record name for comparison
-		recordName := record.Name
-		if !strings.HasSuffix(recordName, ".") {
-			recordName += "."
-		}
+		rrset.Values = append(rrset.Values, record.RData())
+	}
+
+	return rrset, nil
+}
+
+// ReplaceRRSet replaces all values of an RRset with the given ones
+func (c *Client) ReplaceRRSet(ctx context.Context, zone string, rrset *RRSet) error {
+	if !c.IsZoneAllowed(zone) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := rrset.Validate(); err != nil {
+		return fmt.Errorf("invalid record: %w", err)
+	}
+
+	owner, err := ownerName(zone, rrset.Name)
+	if err != nil {
+		return fmt.Errorf("invalid record: %w", err)
+	}
+	existing, err := c.GetRRSet(ctx, zone, owner, rrset.Type)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return err
+	}
 
-		if recordName == searchName && record.Type == recordType {
-			return &record, nil
+	err = c.transaction(ctx, zone, func(t *txn) error {
+		if existing != nil {
+			if err := t.unset(owner, rrset.Type, ""); err != nil {
+				return fmt.Errorf("failed to remove old RRset from zone %s: %w", zone, err)
+			}
 		}
+		for _, value := range rrset.Values {
+			if err := t.set(owner, rrset.TTL, rrset.Type, value); err != nil {
+				return fmt.Errorf("failed to add record to zone %s: %w", zone, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil, fmt.Errorf("record not found: %s %s in zone %s", name, recordType, zone)
+	c.logWrite(ctx, "Replaced RRset: %s %s with %d values in zone %s", owner, rrset.Type, len(rrset.Values), zone)
+	return nil
 }
 
-// CreateRecord creates a new DNS record (idempotent - replaces existing record)
-func (c *Client) CreateRecord(zone string, record *DNSRecord) error {
+// AddRRSetValue adds a single value to an RRset, creating the RRset if
+// needed. A zero TTL keeps the TTL of the existing RRset.
+func (c *Client) AddRRSetValue(ctx context.Context, zone, name string, recordType RecordType, ttl uint32, value string) error {
 	if !c.IsZoneAllowed(zone) {
-		return fmt.Errorf("zone not allowed: %s", zone)
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
 	}
 
-	if err := record.Validate(); err != nil {
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	owner, err := ownerName(zone, name)
+	if err != nil {
+		return fmt.Errorf("invalid record: %w", err)
+	}
+	existing, err := c.GetRRSet(ctx, zone, owner, recordType)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return err
+	}
+	if ttl == 0 && existing != nil {
+		ttl = existing.TTL
+	}
+
+	rrset := &RRSet{Name: owner, Type: recordType, TTL: ttl, Values: []string{value}}
+	if err := rrset.Validate(); err != nil {
 		return fmt.Errorf("invalid record: %w", err)
 	}
 
-	// Check if record already exists
-	existingRecord, err := c.GetRecord(zone, record.Name, record.Type)
-	if err == nil {
-		// Record exists, check if it's identical
-		if existingRecord.TTL == record.TTL &&
-			existingRecord.Data == record.Data &&
-			((existingRecord.Priority == nil && record.Priority == nil) ||
-				(existingRecord.Priority != nil && record.Priority != nil && *existingRecord.Priority == *record.Priority)) {
-			c.logger.Infof("Record already exists with same values: %s %s in zone %s", record.Name, record.Type, zone)
-			return nil // Idempotent - record already exists with same values
+	err = c.transaction(ctx, zone, func(t *txn) error {
+		if err := t.set(owner, rrset.TTL, recordType, rrset.Values[0]); err != nil {
+			return fmt.Errorf("failed to add record to zone %s: %w", zone, err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Use normalized zone name for KnotDNS commands
-	normalizedZone := normalizeZoneName(zone)
+	c.logWrite(ctx, "Added value to RRset: %s %s %s in zone %s", owner, recordType, rrset.Values[0], zone)
+	return nil
+}
 
-	// Begin transaction
-	if _, err := c.executeKnotc("zone-begin", normalizedZone); err != nil {
-		return fmt.Errorf("failed to begin transaction for zone %s: %w", zone, err)
+// RemoveRRSetValue removes a single value from an RRset, leaving the other
+// values in place
+func (c *Client) RemoveRRSetValue(ctx context.Context, zone, name string, recordType RecordType, value string) error {
+	if !c.IsZoneAllowed(zone) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
 	}
 
-	// Add/replace record (zone-set replaces existing records)
-	// KnotDNS zone-set expects: zone-set <zone> <owner> <ttl> <type> <rdata>
-	// For PTR records in reverse zones, we need to extract just the host part
-	recordName := record.Name
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	// Remove trailing dot from record name for processing
-	recordNameClean := strings.TrimSuffix(recordName, ".")
-	zoneNameClean := strings.TrimSuffix(normalizedZone, ".")
+	// Normalize the value so it matches what KnotDNS reports
+	owner, err := ownerName(zone, name)
+	if err != nil {
+		return fmt.Errorf("invalid record: %w", err)
+	}
+	rrset := &RRSet{Name: owner, Type: recordType, Values: []string{value}}
+	if err := rrset.Validate(); err != nil {
+		return fmt.Errorf("invalid record: %w", err)
+	}
+	rdata := rrset.Values[0]
 
-	// For reverse DNS zones, extract just the IP octet
-	if strings.HasSuffix(recordNameClean, "."+zoneNameClean) {
-		// Convert absolute name to relative by removing zone suffix
-		recordName = strings.TrimSuffix(recordNameClean, "."+zoneNameClean)
-	} else if strings.HasSuffix(recordNameClean, zoneNameClean) {
-		// Handle case where record name ends with zone name directly
-		recordName = strings.TrimSuffix(recordNameClean, zoneNameClean)
-		recordName = strings.TrimSuffix(recordName, ".")
-	} else {
-		// For PTR records, try to extract just the first part (IP octet)
-		parts := strings.Split(recordNameClean, ".")
-		if len(parts) > 0 && strings.Contains(recordNameClean, zoneNameClean) {
-			// Extract just the first part (e.g., "6" from "6.143.31.194.in-addr.arpa")
-			recordName = parts[0]
+	existing, err := c.GetRRSet(ctx, zone, owner, recordType)
+	if err != nil {
+		return err
+	}
+	if !containsValue(existing.Values, rdata) {
+		return fmt.Errorf("%w: %s %s %s in zone %s", ErrRecordNotFound, name, recordType, rdata, zone)
+	}
+
+	err = c.transaction(ctx, zone, func(t *txn) error {
+		if err := t.unset(owner, recordType, rdata); err != nil {
+			return fmt.Errorf("failed to remove record from zone %s: %w", zone, err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	args := []string{"zone-set", normalizedZone, recordName,
-		strconv.FormatUint(uint64(record.TTL), 10), string(record.Type)}
+	c.logWrite(ctx, "Removed value from RRset: %s %s %s in zone %s", owner, recordType, rdata, zone)
+	return nil
+}
 
-	// Add priority for MX records
-	if record.Type == RecordTypeMX && record.Priority != nil {
-		args = append(args, strconv.FormatUint(uint64(*record.Priority), 10))
+// ApplyChanges applies an ordered list of changes to a zone inside a single
+// transaction. Either every change is committed or none is.
+func (c *Client) ApplyChanges(ctx context.Context, zone string, changes []Change) error {
+	if !c.IsZoneAllowed(zone) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
 	}
 
-	// Add the record data
-	args = append(args, record.Data)
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	if _, err := c.executeKnotc(args...); err != nil {
-		// Abort transaction on error
-		c.executeKnotc("zone-abort", normalizedZone)
-		return fmt.Errorf("failed to add record to zone %s: %w", zone, err)
+	if len(changes) == 0 {
+		return invalidFieldf("changes", "invalid changes: no changes given")
+	}
+	for i := range changes {
+		if err := changes[i].Validate(); err != nil {
+			return invalidChange(i, err)
+		}
+		if _, err := ownerName(zone, changes[i].Name); err != nil {
+			return invalidChange(i, err)
+		}
 	}
 
-	// Commit transaction
-	if _, err := c.executeKnotc("zone-commit", normalizedZone); err != nil {
-		return fmt.Errorf("failed to commit transaction for zone %s: %w", zone, err)
+	err = c.transaction(ctx, zone, func(t *txn) error {
+		for i, change := range changes {
+			if err := t.apply(change); err != nil {
+				return fmt.Errorf("failed to apply change %d (%s %s %s) to zone %s: %w",
+					i, change.Op, change.Name, change.Type, zone, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if existingRecord != nil {
-		c.logger.Infof("Replaced existing record: %s %s in zone %s", record.Name, record.Type, zone)
-	} else {
-		c.logger.Infof("Created record: %s %s in zone %s", record.Name, record.Type, zone)
+	c.logWrite(ctx, "Applied %d changes to zone %s", len(changes), zone)
+	return nil
+}
+
+// apply executes a single validated change inside the transaction
+func (t *txn) apply(change Change) error {
+	owner, err := ownerName(t.zone, change.Name)
+	if err != nil {
+		return err
+	}
+
+	ttl, current, err := t.get(owner, change.Type)
+	if err != nil {
+		return err
+	}
+	if change.TTL != 0 || len(current) == 0 {
+		ttl = change.TTL
+	}
+	if ttl == 0 {
+		ttl = 300 // Default TTL
+	}
+
+	switch change.Op {
+	case ChangeOpAdd:
+		for _, value := range change.Values {
+			if err := t.set(owner, ttl, change.Type, value); err != nil {
+				return err
+			}
+		}
+	case ChangeOpRemove:
+		if len(current) == 0 {
+			return fmt.Errorf("%w: %s %s in zone %s", ErrRecordNotFound, owner, change.Type, t.zone)
+		}
+		if len(change.Values) == 0 {
+			return t.unset(owner, change.Type, "")
+		}
+		for _, value := range change.Values {
+			if !containsValue(current, value) {
+				return fmt.Errorf("%w: %s %s %s", ErrChangeConflict, owner, change.Type, value)
+			}
+			if err := t.unset(owner, change.Type, value); err != nil {
+				return err
+			}
+		}
+	case ChangeOpReplace:
+		if len(current) > 0 {
+			if err := t.unset(owner, change.Type, ""); err != nil {
+				return err
+			}
+		}
+		for _, value := range change.Values {
+			if err := t.set(owner, ttl, change.Type, value); err != nil {
+				return err
+			}
+		}
 	}
+
 	return nil
 }
 
-// UpdateRecord updates an existing DNS record
-func (c *Client) UpdateRecord(zone, name string, recordType RecordType, updates *UpdateRecordRequest) error {
+// containsValue reports whether values contains value
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateRecord adds a record to the RRset of its name and type, creating
+// the RRset if needed. zone-set gives the whole RRset the TTL of the new
+// record. Creating a record that already exists with the same TTL changes
+// nothing.
+func (c *Client) CreateRecord(ctx context.Context, zone string, record *DNSRecord) error {
 	if !c.IsZoneAllowed(zone) {
-		return fmt.Errorf("zone not allowed: %s", zone)
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
 	}
 
-	// Get existing record
-	existingRecord, err := c.GetRecord(zone, name, recordType)
+	unlock, err := c.lockZone(ctx, zone)
 	if err != nil {
-		return fmt.Errorf("record not found: %w", err)
+		return err
 	}
+	defer unlock()
 
-	// Apply updates
-	if updates.TTL != nil {
-		existingRecord.TTL = *updates.TTL
+	if err := record.Validate(); err != nil {
+		return fmt.Errorf("invalid record: %w", err)
 	}
-	if updates.Data != nil {
-		existingRecord.Data = *updates.Data
+
+	owner, err := ownerName(zone, record.Name)
+	if err != nil {
+		return fmt.Errorf("invalid record: %w", err)
 	}
-	if updates.Priority != nil {
-		existingRecord.Priority = updates.Priority
+	record.Name = owner
+	qualifyRecord(zone, record)
+
+	// Compare with every value of the RRset, not just the first one
+	existing, err := c.GetRRSet(ctx, zone, owner, record.Type)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return err
+	}
+	if existing != nil && existing.TTL == record.TTL && containsValue(existing.Values, record.RData()) {
+		c.logger.Infof("Record already exists with same values: %s %s in zone %s", owner, record.Type, zone)
+		return nil
 	}
 
-	// Validate updated record
-	if err := existingRecord.Validate(); err != nil {
-		return fmt.Errorf("invalid updated record: %w", err)
+	err = c.transaction(ctx, zone, func(t *txn) error {
+		if err := t.set(owner, record.TTL, record.Type, record.RData()); err != nil {
+			return fmt.Errorf("failed to add record to zone %s: %w", zone, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Use normalized zone name for KnotDNS commands
-	normalizedZone := normalizeZoneName(zone)
+	if existing != nil {
+		c.logWrite(ctx, "Added record to existing RRset: %s %s in zone %s", owner, record.Type, zone)
+	} else {
+		c.logWrite(ctx, "Created record: %s %s in zone %s", owner, record.Type, zone)
+	}
+	return nil
+}
 
-	// Begin transaction
-	if _, err := c.executeKnotc("zone-begin", normalizedZone); err != nil {
-		return fmt.Errorf("failed to begin transaction for zone %s: %w", zone, err)
+// UpdateRecord updates an existing DNS record
+func (c *Client) UpdateRecord(ctx context.Context, zone, name string, recordType RecordType, updates *UpdateRecordRequest) error {
+	if !c.IsZoneAllowed(zone) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
 	}
 
-	// Remove old record using name and type only (simpler and more reliable)
-	if _, err := c.executeKnotc("zone-unset", normalizedZone, existingRecord.Name, string(existingRecord.Type)); err != nil {
-		c.executeKnotc("zone-abort", normalizedZone)
-		return fmt.Errorf("failed to remove old record from zone %s: %w", zone, err)
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	// Add updated record using separate arguments
-	// For PTR records in reverse zones, we need to extract just the host part
-	recordName := existingRecord.Name
+	owner, err := ownerName(zone, name)
+	if err != nil {
+		return fmt.Errorf("invalid record: %w", err)
+	}
 
-	// Remove trailing dot from record name for processing
-	recordNameClean := strings.TrimSuffix(recordName, ".")
-	zoneNameClean := strings.TrimSuffix(normalizedZone, ".")
+	// Get the existing RRset and pick the value to update
+	rrset, err := c.GetRRSet(ctx, zone, owner, recordType)
+	if err != nil {
+		return err
+	}
 
-	// For reverse DNS zones, extract just the IP octet
-	if strings.HasSuffix(recordNameClean, "."+zoneNameClean) {
-		// Convert absolute name to relative by removing zone suffix
-		recordName = strings.TrimSuffix(recordNameClean, "."+zoneNameClean)
-	} else if strings.HasSuffix(recordNameClean, zoneNameClean) {
-		// Handle case where record name ends with zone name directly
-		recordName = strings.TrimSuffix(recordNameClean, zoneNameClean)
-		recordName = strings.TrimSuffix(recordName, ".")
-	} else {
-		// For PTR records, try to extract just the first part (IP octet)
-		parts := strings.Split(recordNameClean, ".")
-		if len(parts) > 0 && strings.Contains(recordNameClean, zoneNameClean) {
-			// Extract just the first part (e.g., "6" from "6.143.31.194.in-addr.arpa")
-			recordName = parts[0]
+	oldRData := rrset.Values[0]
+	if updates.Match != nil {
+		match := &RRSet{Name: rrset.Name, Type: recordType, Values: []string{*updates.Match}}
+		if err := match.Validate(); err != nil {
+			return invalidField("match", fmt.Errorf("invalid match: %w", err))
+		}
+		oldRData = ""
+		for _, value := range rrset.Values {
+			if value == match.Values[0] {
+				oldRData = value
+				break
+			}
 		}
+		if oldRData == "" {
+			return fmt.Errorf("%w: %s %s %s in zone %s", ErrRecordNotFound, name, recordType, match.Values[0], zone)
+		}
+	} else if len(rrset.Values) > 1 {
+		return fmt.Errorf("%w: %s %s has %d values in zone %s, set match to select one", ErrAmbiguousUpdate,
+			name, recordType, len(rrset.Values), zone)
 	}
 
-	args := []string{"zone-set", normalizedZone, recordName,
-		strconv.FormatUint(uint64(existingRecord.TTL), 10), string(existingRecord.Type)}
-
-	// Add priority for MX records
-	if existingRecord.Type == RecordTypeMX && existingRecord.Priority != nil {
-		args = append(args, strconv.FormatUint(uint64(*existingRecord.Priority), 10))
+	existingRecord, err := newRecord(rrset.Name, strconv.FormatUint(uint64(rrset.TTL), 10), string(recordType), oldRData)
+	if err != nil {
+		return err
 	}
 
-	// Add the record data
-	args = append(args, existingRecord.Data)
+	// Apply updates
+	if updates.TTL != nil {
+		existingRecord.TTL = *updates.TTL
+	}
+	if updates.Data != nil || !updates.TypedData.isEmpty() {
+		// New data replaces the typed fields parsed from the old value
+		existingRecord.TypedData = updates.TypedData
+	}
+	if updates.Data != nil {
+		existingRecord.Data = *updates.Data
+	}
+	if updates.Priority != nil {
+		existingRecord.Priority = updates.Priority
+	}
 
-	if _, err := c.executeKnotc(args...); err != nil {
-		c.executeKnotc("zone-abort", normalizedZone)
-		return fmt.Errorf("failed to add updated record to zone %s: %w", zone, err)
+	// Validate updated record
+	if err := existingRecord.Validate(); err != nil {
+		return fmt.Errorf("invalid updated record: %w", err)
 	}
 
-	// Commit transaction
-	if _, err := c.executeKnotc("zone-commit", normalizedZone); err != nil {
-		return fmt.Errorf("failed to commit transaction for zone %s: %w", zone, err)
+	err = c.transaction(ctx, zone, func(t *txn) error {
+		// Remove only the old value so that the rest of the RRset survives
+		if err := t.unset(rrset.FQDN, recordType, oldRData); err != nil {
+			return fmt.Errorf("failed to remove old record from zone %s: %w", zone, err)
+		}
+
+		if err := t.set(rrset.FQDN, existingRecord.TTL, recordType, existingRecord.RData()); err != nil {
+			return fmt.Errorf("failed to add updated record to zone %s: %w", zone, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	c.logger.Infof("Updated record: %s %s in zone %s", existingRecord.Name, existingRecord.Type, zone)
+	c.logWrite(ctx, "Updated record: %s %s in zone %s", rrset.FQDN, existingRecord.Type, zone)
 	return nil
 }
 
-// DeleteRecord deletes a DNS record
-func (c *Client) DeleteRecord(zone, name string, recordType RecordType) error {
+// DeleteRecord deletes every record of the given type at a name. Use
+// RemoveRRSetValue to delete a single value.
+func (c *Client) DeleteRecord(ctx context.Context, zone, name string, recordType RecordType) error {
 	if !c.IsZoneAllowed(zone) {
-		return fmt.Errorf("zone not allowed: %s", zone)
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
 	}
 
-	// Check if record exists and get the full record for precise deletion
-	existingRecord, err := c.GetRecord(zone, name, recordType)
+	unlock, err := c.lockZone(ctx, zone)
 	if err != nil {
-		return fmt.Errorf("record not found: %w", err)
+		return err
 	}
+	defer unlock()
 
-	// Use normalized zone name for KnotDNS commands
-	normalizedZone := normalizeZoneName(zone)
-
-	// Begin transaction
-	if _, err := c.executeKnotc("zone-begin", normalizedZone); err != nil {
-		return fmt.Errorf("failed to begin transaction for zone %s: %w", zone, err)
+	owner, err := ownerName(zone, name)
+	if err != nil {
+		return fmt.Errorf("invalid record: %w", err)
 	}
 
-	// Remove record using name and type only (simpler and more reliable)
-	if _, err := c.executeKnotc("zone-unset", normalizedZone, existingRecord.Name, string(existingRecord.Type)); err != nil {
-		c.executeKnotc("zone-abort", normalizedZone)
-		return fmt.Errorf("failed to remove record from zone %s: %w", zone, err)
+	// Check if record exists and get the full record for precise deletion
+	existingRecord, err := c.GetRecord(ctx, zone, owner, recordType)
+	if err != nil {
+		return err
 	}
 
-	// Commit transaction
-	if _, err := c.executeKnotc("zone-commit", normalizedZone); err != nil {
-		return fmt.Errorf("failed to commit transaction for zone %s: %w", zone, err)
+	err = c.transaction(ctx, zone, func(t *txn) error {
+		// Remove record using name and type only (simpler and more reliable)
+		if err := t.unset(existingRecord.FQDN, existingRecord.Type, ""); err != nil {
+			return fmt.Errorf("failed to remove record from zone %s: %w", zone, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	c.logger.Infof("Deleted record: %s %s from zone %s", name, recordType, zone)
+	c.logWrite(ctx, "Deleted record: %s %s from zone %s", existingRecord.FQDN, recordType, zone)
 	return nil
 }
 
 // ReloadZone reloads a zone configuration
-func (c *Client) ReloadZone(zone string) error {
+func (c *Client) ReloadZone(ctx context.Context, zone string) error {
 	if !c.IsZoneAllowed(zone) {
-		return fmt.Errorf("zone not allowed: %s", zone)
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
 	}
 
-	if _, err := c.executeKnotc("zone-reload", zone); err != nil {
+	if err := c.command(ctx, ctlData{ctlIdxCmd: "zone-reload", ctlIdxZone: normalizeZoneName(zone)}, nil); err != nil {
 		return fmt.Errorf("failed to reload zone %s: %w", zone, err)
 	}
 
@@ -400,17 +980,34 @@ func (c *Client) ReloadZone(zone string) error {
 	return nil
 }
 
+// zoneCommand runs a control command that only takes a zone, plus
+// optional extra fields, and logs it
+func (c *Client) zoneCommand(ctx context.Context, zone string, req ctlData) error {
+	if !c.IsZoneAllowed(zone) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	req[ctlIdxZone] = normalizeZoneName(zone)
+	if err := c.command(ctx, req, nil); err != nil {
+		return fmt.Errorf("%s of zone %s failed: %w", req[ctlIdxCmd], zone, err)
+	}
+
+	c.logger.Infof("Executed %s on zone %s", req[ctlIdxCmd], zone)
+	return nil
+}
+
 // CheckHealth checks if KnotDNS is running and accessible
-func (c *Client) CheckHealth() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (c *Client) CheckHealth(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, c.knotcPath, "status")
-	if c.socketPath != "" {
-		cmd.Args = append(cmd.Args[:1], append([]string{"-s", c.socketPath}, cmd.Args[1:]...)...)
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return unavailable(fmt.Errorf("KnotDNS health check failed: %w", err))
 	}
+	defer cn.close()
 
-	if err := cmd.Run(); err != nil {
+	if err := cn.exec(ctx, ctlData{ctlIdxCmd: "status"}, nil); err != nil {
 		return fmt.Errorf("KnotDNS health check failed: %w", err)
 	}
 
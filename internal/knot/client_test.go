@@ -0,0 +1,83 @@
+package knot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// cutOffBeginConn passes commands on to a memory session, but reports a
+// zone-begin as interrupted after knotd opened the transaction, like a
+// request cancelled while the command was in flight
+type cutOffBeginConn struct {
+	conn
+	cancel context.CancelFunc
+}
+
+func (c *cutOffBeginConn) exec(ctx context.Context, req ctlData, fn func(ctlData) error) error {
+	if err := c.conn.exec(ctx, req, fn); err != nil || req[ctlIdxCmd] != "zone-begin" {
+		return err
+	}
+	c.cancel()
+	return fmt.Errorf("zone-begin interrupted: %w", context.Canceled)
+}
+
+func TestTransactionAbortsInterruptedBegin(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	memory := NewMemoryBackend([]string{"example.com"}, Options{}, logger).(*Client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newClient(Options{}, func(ctx context.Context) (conn, error) {
+		cn, err := memory.dial(ctx)
+		return &cutOffBeginConn{conn: cn, cancel: cancel}, err
+	}, logger)
+
+	err := c.transaction(ctx, "example.com", func(*txn) error {
+		t.Error("transaction ran after zone-begin was interrupted")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("transaction error = %v, want context.Canceled", err)
+	}
+
+	status, err := memory.GetZoneStatus(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("GetZoneStatus: %v", err)
+	}
+	if status.Transaction {
+		t.Errorf("zone transaction left open after an interrupted zone-begin")
+	}
+}
+
+func TestLockZoneHonoursContext(t *testing.T) {
+	c := newTestClient(t)
+
+	unlock, err := c.lockZone(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("lockZone: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	record := &DNSRecord{Name: "new", Type: RecordTypeA, TTL: 300, Data: "192.0.2.3"}
+	if err := c.CreateRecord(ctx, "example.com", record); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CreateRecord while the zone is locked = %v, want context.DeadlineExceeded", err)
+	}
+
+	// Other zones are not held up
+	if _, err := c.lockZone(context.Background(), "example.net"); err != nil {
+		t.Errorf("lockZone of another zone: %v", err)
+	}
+
+	unlock()
+	if err := c.CreateRecord(context.Background(), "example.com", record); err != nil {
+		t.Errorf("CreateRecord after unlock: %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package knot
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"time"
+)
+
+// ctlType is the type of a control protocol unit
+type ctlType byte
+
+// Control unit types as defined by libknot/control
+const (
+	ctlTypeEnd ctlType = iota
+	ctlTypeData
+	ctlTypeExtra
+	ctlTypeBlock
+)
+
+// ctlIdx identifies a data item within a DATA or EXTRA unit
+type ctlIdx int
+
+// Control data item indexes as defined by libknot/control
+const (
+	ctlIdxCmd ctlIdx = iota
+	ctlIdxFlags
+	ctlIdxError
+	ctlIdxSection
+	ctlIdxItem
+	ctlIdxID
+	ctlIdxZone
+	ctlIdxOwner
+	ctlIdxTTL
+	ctlIdxType
+	ctlIdxData
+	ctlIdxFilter
+	ctlIdxCount
+)
+
+// ctlDataCodeOffset is added to a data item index to form its wire code
+const ctlDataCodeOffset = 0x10
+
+// ctlData holds the items of a single DATA or EXTRA unit; empty strings
+// are treated as absent items
+type ctlData [ctlIdxCount]string
+
+// conn is a control session with knotd. All commands sent through one conn
+// share a single control connection where the transport supports it.
+type conn interface {
+	// exec sends a command and calls fn for every data unit of the
+	// response. It gives up once ctx is done.
+	exec(ctx context.Context, req ctlData, fn func(ctlData) error) error
+	close() error
+}
+
+// errInterrupted is returned by a connection whose session was cut off by
+// a cancelled command and can no longer be used
+var errInterrupted = errors.New("control connection was interrupted")
+
+// socketConn speaks the libknot control protocol (TLV framing) directly
+// over the knotd control socket
+type socketConn struct {
+	conn        net.Conn
+	r           *bufio.Reader
+	w           *bufio.Writer
+	interrupted bool // a command was cut off, the session is out of sync
+	closed      bool
+}
+
+// dialSocket connects to the knotd control socket at path
+func dialSocket(ctx context.Context, path string) (*socketConn, error) {
+	if path == "" {
+		return nil, fmt.Errorf("control socket path is not configured")
+	}
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to control socket %s: %w", path, err)
+	}
+
+	return &socketConn{
+		conn: nc,
+		r:    bufio.NewReader(nc),
+		w:    bufio.NewWriter(nc),
+	}, nil
+}
+
+// exec runs a command, bounded by the deadline of ctx. Cancelling ctx
+// interrupts a command that is waiting on knotd.
+func (s *socketConn) exec(ctx context.Context, req ctlData, fn func(ctlData) error) error {
+	if s.interrupted {
+		return errInterrupted
+	}
+
+	deadline, _ := ctx.Deadline() // zero means no deadline
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		s.conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	err := s.roundTrip(req, fn)
+	if err != nil && ctx.Err() != nil {
+		s.interrupted = true
+		return fmt.Errorf("%s interrupted: %w", req[ctlIdxCmd], ctx.Err())
+	}
+	return err
+}
+
+// roundTrip sends a single command followed by a BLOCK unit and reads the
+// response until the server terminates it with its own BLOCK unit
+func (s *socketConn) roundTrip(req ctlData, fn func(ctlData) error) error {
+	if err := s.send(ctlTypeData, &req); err != nil {
+		return err
+	}
+	if err := s.send(ctlTypeBlock, nil); err != nil {
+		return err
+	}
+	if err := s.w.Flush(); err != nil {
+		return unavailable(fmt.Errorf("failed to send control command: %w", err))
+	}
+
+	// Always drain the response up to the BLOCK unit so that the connection
+	// stays usable for the next command, even after an error
+	var last ctlData
+	var result error
+	for {
+		unitType, data, err := s.receive()
+		if err != nil {
+			return unavailable(fmt.Errorf("failed to receive control response: %w", err))
+		}
+
+		switch unitType {
+		case ctlTypeBlock:
+			return result
+		case ctlTypeEnd:
+			if result != nil {
+				return result
+			}
+			return unavailable(errors.New("control connection closed by server"))
+		case ctlTypeExtra:
+			// EXTRA units continue the previous DATA unit
+			for i := range data {
+				if data[i] == "" {
+					data[i] = last[i]
+				}
+			}
+		}
+		last = data
+
+		if result != nil {
+			continue
+		}
+		if data[ctlIdxError] != "" {
+			result = fmt.Errorf("%s failed: %s", req[ctlIdxCmd], data[ctlIdxError])
+			continue
+		}
+		if fn != nil {
+			result = fn(data)
+		}
+	}
+}
+
+// close ends the control session and closes the connection. Closing it
+// again does nothing.
+func (s *socketConn) close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.send(ctlTypeEnd, nil)
+	s.w.Flush()
+	return s.conn.Close()
+}
+
+// send writes a single unit to the output buffer
+func (s *socketConn) send(unitType ctlType, data *ctlData) error {
+	if err := s.w.WriteByte(byte(unitType)); err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+
+	for i, value := range data {
+		if value == "" {
+			continue
+		}
+		if len(value) > math.MaxUint16 {
+			return fmt.Errorf("control data item too long: %d bytes", len(value))
+		}
+
+		var header [3]byte
+		header[0] = byte(ctlDataCodeOffset + i)
+		binary.BigEndian.PutUint16(header[1:], uint16(len(value)))
+		if _, err := s.w.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := s.w.WriteString(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// receive reads a single unit. Items of a DATA or EXTRA unit follow the
+// type byte until the next type byte is seen.
+func (s *socketConn) receive() (ctlType, ctlData, error) {
+	var data ctlData
+
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return 0, data, err
+	}
+
+	unitType := ctlType(b)
+	switch unitType {
+	case ctlTypeEnd, ctlTypeBlock:
+		return unitType, data, nil
+	case ctlTypeData, ctlTypeExtra:
+	default:
+		return 0, data, fmt.Errorf("invalid control unit type: %d", b)
+	}
+
+	for {
+		next, err := s.r.Peek(1)
+		if err != nil {
+			return 0, data, err
+		}
+		if next[0] < ctlDataCodeOffset {
+			return unitType, data, nil
+		}
+
+		code, _ := s.r.ReadByte()
+		var length [2]byte
+		if _, err := io.ReadFull(s.r, length[:]); err != nil {
+			return 0, data, err
+		}
+		value := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(s.r, value); err != nil {
+			return 0, data, err
+		}
+
+		// Skip items introduced by newer protocol revisions
+		if idx := int(code - ctlDataCodeOffset); idx < int(ctlIdxCount) {
+			data[idx] = string(value)
+		}
+	}
+}
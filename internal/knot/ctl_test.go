@@ -0,0 +1,283 @@
+package knot
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testSocketConn returns a socketConn that reads the units in response and
+// writes what it sends to out
+func testSocketConn(response []byte, out *bytes.Buffer) *socketConn {
+	return &socketConn{
+		r: bufio.NewReader(bytes.NewReader(response)),
+		w: bufio.NewWriter(out),
+	}
+}
+
+// The byte sequences below are laid out as knotc and knotd put them on the
+// wire: a type byte per unit, then for DATA and EXTRA units one item per
+// code (0x10 + index) with a big-endian uint16 length.
+
+func TestSocketConnSend(t *testing.T) {
+	tests := []struct {
+		name string
+		req  ctlData
+		want []byte
+	}{
+		{
+			name: "status",
+			req:  ctlData{ctlIdxCmd: "status"},
+			want: []byte{
+				0x01,
+				0x10, 0x00, 0x06, 's', 't', 'a', 't', 'u', 's',
+				0x03,
+			},
+		},
+		{
+			name: "zone-read with zone and type",
+			req:  ctlData{ctlIdxCmd: "zone-read", ctlIdxZone: "example.com.", ctlIdxType: "A"},
+			want: []byte{
+				0x01,
+				0x10, 0x00, 0x09, 'z', 'o', 'n', 'e', '-', 'r', 'e', 'a', 'd',
+				0x16, 0x00, 0x0c, 'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm', '.',
+				0x19, 0x00, 0x01, 'A',
+				0x03,
+			},
+		},
+		{
+			name: "zone-set with flags",
+			req: ctlData{
+				ctlIdxCmd: "zone-set", ctlIdxFlags: "F", ctlIdxZone: "a.", ctlIdxOwner: "www",
+				ctlIdxTTL: "300", ctlIdxType: "A", ctlIdxData: "192.0.2.1",
+			},
+			want: []byte{
+				0x01,
+				0x10, 0x00, 0x08, 'z', 'o', 'n', 'e', '-', 's', 'e', 't',
+				0x11, 0x00, 0x01, 'F',
+				0x16, 0x00, 0x02, 'a', '.',
+				0x17, 0x00, 0x03, 'w', 'w', 'w',
+				0x18, 0x00, 0x03, '3', '0', '0',
+				0x19, 0x00, 0x01, 'A',
+				0x1a, 0x00, 0x09, '1', '9', '2', '.', '0', '.', '2', '.', '1',
+				0x03,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			s := testSocketConn([]byte{0x03}, &out)
+			if err := s.roundTrip(tt.req, nil); err != nil {
+				t.Fatalf("roundTrip: %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), tt.want) {
+				t.Errorf("sent % x\nwant % x", out.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSocketConnSendLength(t *testing.T) {
+	var out bytes.Buffer
+	s := testSocketConn(nil, &out)
+
+	value := strings.Repeat("x", 0x012c)
+	if err := s.send(ctlTypeData, &ctlData{ctlIdxData: value}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	s.w.Flush()
+
+	want := append([]byte{0x01, 0x1a, 0x01, 0x2c}, value...)
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("sent % x, want % x", out.Bytes()[:4], want[:4])
+	}
+
+	err := s.send(ctlTypeData, &ctlData{ctlIdxData: strings.Repeat("x", math.MaxUint16+1)})
+	if err == nil {
+		t.Errorf("send of an item over %d bytes succeeded, want an error", math.MaxUint16)
+	}
+}
+
+func TestSocketConnReceive(t *testing.T) {
+	tests := []struct {
+		name     string
+		response []byte
+		want     []ctlData
+	}{
+		{
+			name:     "empty response",
+			response: []byte{0x03},
+			want:     nil,
+		},
+		{
+			name: "single data unit",
+			response: []byte{
+				0x01,
+				0x10, 0x00, 0x06, 's', 't', 'a', 't', 'u', 's',
+				0x1a, 0x00, 0x07, 'R', 'u', 'n', 'n', 'i', 'n', 'g',
+				0x03,
+			},
+			want: []ctlData{
+				{ctlIdxCmd: "status", ctlIdxData: "Running"},
+			},
+		},
+		{
+			name: "extra units continue the data unit",
+			response: []byte{
+				0x01,
+				0x16, 0x00, 0x02, 'a', '.',
+				0x17, 0x00, 0x02, 'a', '.',
+				0x18, 0x00, 0x04, '3', '6', '0', '0',
+				0x19, 0x00, 0x02, 'N', 'S',
+				0x1a, 0x00, 0x04, 'n', 's', '1', '.',
+				0x02,
+				0x1a, 0x00, 0x04, 'n', 's', '2', '.',
+				0x02,
+				0x19, 0x00, 0x01, 'A',
+				0x1a, 0x00, 0x09, '1', '9', '2', '.', '0', '.', '2', '.', '1',
+				0x03,
+			},
+			want: []ctlData{
+				{ctlIdxZone: "a.", ctlIdxOwner: "a.", ctlIdxTTL: "3600", ctlIdxType: "NS", ctlIdxData: "ns1."},
+				{ctlIdxZone: "a.", ctlIdxOwner: "a.", ctlIdxTTL: "3600", ctlIdxType: "NS", ctlIdxData: "ns2."},
+				{ctlIdxZone: "a.", ctlIdxOwner: "a.", ctlIdxTTL: "3600", ctlIdxType: "A", ctlIdxData: "192.0.2.1"},
+			},
+		},
+		{
+			name: "two byte length",
+			response: append(append([]byte{
+				0x01,
+				0x1a, 0x01, 0x00,
+			}, strings.Repeat("y", 256)...), 0x03),
+			want: []ctlData{
+				{ctlIdxData: strings.Repeat("y", 256)},
+			},
+		},
+		{
+			name: "unknown item codes are skipped",
+			response: []byte{
+				0x01,
+				0x10, 0x00, 0x06, 's', 't', 'a', 't', 'u', 's',
+				0x1f, 0x00, 0x03, 'n', 'e', 'w',
+				0x1a, 0x00, 0x02, 'o', 'k',
+				0x03,
+			},
+			want: []ctlData{
+				{ctlIdxCmd: "status", ctlIdxData: "ok"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			s := testSocketConn(tt.response, &out)
+
+			var got []ctlData
+			err := s.roundTrip(ctlData{ctlIdxCmd: "status"}, func(data ctlData) error {
+				got = append(got, data)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("roundTrip: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("received %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSocketConnReceiveError(t *testing.T) {
+	response := []byte{
+		0x01,
+		0x10, 0x00, 0x08, 'z', 'o', 'n', 'e', '-', 'g', 'e', 't',
+		0x12, 0x00, 0x0c, 'n', 'o', ' ', 's', 'u', 'c', 'h', ' ', 'z', 'o', 'n', 'e',
+		0x01,
+		0x1a, 0x00, 0x01, 'x',
+		0x03,
+		// The next response must still be readable
+		0x01,
+		0x1a, 0x00, 0x02, 'o', 'k',
+		0x03,
+	}
+
+	var out bytes.Buffer
+	s := testSocketConn(response, &out)
+
+	called := false
+	err := s.roundTrip(ctlData{ctlIdxCmd: "zone-get"}, func(ctlData) error {
+		called = true
+		return nil
+	})
+	if err == nil || err.Error() != "zone-get failed: no such zone" {
+		t.Fatalf("roundTrip error = %v, want the error item", err)
+	}
+	if called {
+		t.Errorf("fn was called for units after the error")
+	}
+
+	var got []ctlData
+	err = s.roundTrip(ctlData{ctlIdxCmd: "status"}, func(data ctlData) error {
+		got = append(got, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("second roundTrip: %v", err)
+	}
+	if want := []ctlData{{ctlIdxData: "ok"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second response %q, want %q", got, want)
+	}
+}
+
+func TestSocketConnReceiveEnd(t *testing.T) {
+	tests := []struct {
+		name     string
+		response []byte
+	}{
+		{name: "end unit", response: []byte{0x00}},
+		{name: "eof", response: []byte{0x01, 0x10, 0x00, 0x06, 's', 't'}},
+		{name: "invalid unit type", response: []byte{0x07}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			s := testSocketConn(tt.response, &out)
+
+			err := s.roundTrip(ctlData{ctlIdxCmd: "status"}, nil)
+			if !errors.Is(err, ErrUnavailable) {
+				t.Errorf("roundTrip error = %v, want ErrUnavailable", err)
+			}
+		})
+	}
+}
+
+func TestSocketConnClose(t *testing.T) {
+	client, server := net.Pipe()
+	s := &socketConn{conn: client, r: bufio.NewReader(client), w: bufio.NewWriter(client)}
+
+	received := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(server)
+		received <- b
+	}()
+
+	if err := s.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := s.close(); err != nil {
+		t.Errorf("second close: %v", err)
+	}
+	if got := <-received; !bytes.Equal(got, []byte{0x00}) {
+		t.Errorf("close sent % x, want an END unit", got)
+	}
+}
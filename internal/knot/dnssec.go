@@ -0,0 +1,234 @@
+package knot
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Key types accepted by zone-key-rollover
+const (
+	KeyTypeKSK = "ksk"
+	KeyTypeZSK = "zsk"
+)
+
+// DS digest types (RFC 4509, RFC 6605)
+const (
+	DigestSHA256 uint8 = 2
+	DigestSHA384 uint8 = 4
+)
+
+// dnskeyFlagSEP marks a DNSKEY as key signing key (RFC 4034, section 2.1.1)
+const dnskeyFlagSEP = 0x0001
+
+// dnskey holds the fields of a DNSKEY record needed to derive DS records
+type dnskey struct {
+	algorithm uint8
+	keyTag    uint16
+	ksk       bool
+}
+
+// DSRecord represents a DS record derived from a zone's KSK
+type DSRecord struct {
+	Name       string `json:"name"`
+	TTL        uint32 `json:"ttl"`
+	KeyTag     uint16 `json:"key_tag"`
+	Algorithm  uint8  `json:"algorithm"`
+	DigestType uint8  `json:"digest_type"`
+	Digest     string `json:"digest"`
+	Data       string `json:"data"`
+}
+
+// DNSSECRecords holds the DNSSEC records published at a zone apex
+type DNSSECRecords struct {
+	DNSKEY  []DNSRecord `json:"dnskey"`
+	CDS     []DNSRecord `json:"cds"`
+	CDNSKEY []DNSRecord `json:"cdnskey"`
+}
+
+// parseDNSKEY parses DNSKEY record data in presentation format
+func parseDNSKEY(rdata string) (*dnskey, []byte, error) {
+	fields := strings.Fields(rdata)
+	if len(fields) < 4 {
+		return nil, nil, fmt.Errorf("invalid DNSKEY record data: %s", rdata)
+	}
+
+	flags, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DNSKEY flags: %s", fields[0])
+	}
+	protocol, err := strconv.ParseUint(fields[1], 10, 8)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DNSKEY protocol: %s", fields[1])
+	}
+	algorithm, err := strconv.ParseUint(fields[2], 10, 8)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DNSKEY algorithm: %s", fields[2])
+	}
+
+	// The public key may be split into several base64 chunks
+	publicKey := strings.Join(fields[3:], "")
+	keyData, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DNSKEY public key: %w", err)
+	}
+
+	// Wire format of the RDATA
+	wire := make([]byte, 4, 4+len(keyData))
+	binary.BigEndian.PutUint16(wire, uint16(flags))
+	wire[2] = uint8(protocol)
+	wire[3] = uint8(algorithm)
+	wire = append(wire, keyData...)
+
+	key := &dnskey{
+		algorithm: uint8(algorithm),
+		keyTag:    keyTag(wire),
+		ksk:       flags&dnskeyFlagSEP != 0,
+	}
+	return key, wire, nil
+}
+
+// keyTag computes the key tag of DNSKEY wire data (RFC 4034, appendix B)
+func keyTag(wire []byte) uint16 {
+	// Algorithm 1 (RSA/MD5) uses the low bits of the modulus
+	if len(wire) > 4 && wire[3] == 1 {
+		return binary.BigEndian.Uint16(wire[len(wire)-3:])
+	}
+
+	var ac uint32
+	for i, b := range wire {
+		if i&1 == 1 {
+			ac += uint32(b)
+		} else {
+			ac += uint32(b) << 8
+		}
+	}
+	ac += ac >> 16 & 0xFFFF
+	return uint16(ac & 0xFFFF)
+}
+
+// nameWire returns the canonical wire format of an absolute domain name
+func nameWire(name string) []byte {
+	var wire []byte
+	for _, label := range strings.Split(strings.TrimSuffix(strings.ToLower(name), "."), ".") {
+		if label == "" {
+			continue
+		}
+		wire = append(wire, byte(len(label)))
+		wire = append(wire, label...)
+	}
+	return append(wire, 0)
+}
+
+// deriveDS computes the DS record for a DNSKEY at owner (RFC 4034, section 5.1.4)
+func deriveDS(owner string, key *dnskey, wire []byte, digestType uint8) (*DSRecord, error) {
+	data := append(nameWire(owner), wire...)
+
+	var digest []byte
+	switch digestType {
+	case DigestSHA256:
+		sum := sha256.Sum256(data)
+		digest = sum[:]
+	case DigestSHA384:
+		sum := sha512.Sum384(data)
+		digest = sum[:]
+	default:
+		return nil, fmt.Errorf("invalid digest type: %d", digestType)
+	}
+
+	ds := &DSRecord{
+		Name:       owner,
+		KeyTag:     key.keyTag,
+		Algorithm:  key.algorithm,
+		DigestType: digestType,
+		Digest:     strings.ToUpper(hex.EncodeToString(digest)),
+	}
+	ds.Data = fmt.Sprintf("%d %d %d %s", ds.KeyTag, ds.Algorithm, ds.DigestType, ds.Digest)
+	return ds, nil
+}
+
+// SignZone re-signs a zone, replacing all existing signatures
+func (c *Client) SignZone(ctx context.Context, zone string) error {
+	return c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-sign"})
+}
+
+// RolloverKey starts a rollover of the zone's KSK or ZSK
+func (c *Client) RolloverKey(ctx context.Context, zone, keyType string) error {
+	keyType = strings.ToLower(keyType)
+	if keyType != KeyTypeKSK && keyType != KeyTypeZSK {
+		return invalidFieldf("key", "invalid key type: %s", keyType)
+	}
+	return c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-key-rollover", ctlIdxType: keyType})
+}
+
+// SubmitKSK confirms that the new KSK's DS has been published in the parent
+// zone, letting a KSK rollover proceed
+func (c *Client) SubmitKSK(ctx context.Context, zone string) error {
+	return c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-ksk-submitted"})
+}
+
+// GetDNSSECRecords returns the DNSKEY, CDS and CDNSKEY records at the zone apex
+func (c *Client) GetDNSSECRecords(ctx context.Context, zone string) (*DNSSECRecords, error) {
+	records, err := c.FindRecords(ctx, zone, "@", "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DNSSECRecords{
+		DNSKEY:  []DNSRecord{},
+		CDS:     []DNSRecord{},
+		CDNSKEY: []DNSRecord{},
+	}
+	for _, record := range records {
+		switch record.Type {
+		case RecordTypeDNSKEY:
+			result.DNSKEY = append(result.DNSKEY, record)
+		case RecordTypeCDS:
+			result.CDS = append(result.CDS, record)
+		case RecordTypeCDNSKEY:
+			result.CDNSKEY = append(result.CDNSKEY, record)
+		}
+	}
+
+	return result, nil
+}
+
+// GetDS derives DS records for the zone's key signing keys, ready to be
+// submitted to the parent zone
+func (c *Client) GetDS(ctx context.Context, zone string, digestType uint8) ([]DSRecord, error) {
+	records, err := c.FindRecords(ctx, zone, "@", RecordTypeDNSKEY)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := strings.ToLower(normalizeZoneName(zone))
+	dsRecords := []DSRecord{}
+	for _, record := range records {
+		key, wire, err := parseDNSKEY(record.Data)
+		if err != nil {
+			return nil, err
+		}
+		if !key.ksk {
+			continue
+		}
+
+		ds, err := deriveDS(owner, key, wire, digestType)
+		if err != nil {
+			return nil, err
+		}
+		ds.TTL = record.TTL
+		dsRecords = append(dsRecords, *ds)
+	}
+
+	if len(dsRecords) == 0 {
+		return nil, fmt.Errorf("%w: no KSK published in zone %s", ErrRecordNotFound, zone)
+	}
+
+	return dsRecords, nil
+}
@@ -0,0 +1,141 @@
+package knot
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Known answers from the examples of RFC 4034 section 5.4, RFC 4509
+// section 2.3 and RFC 8080 section 6.1
+const (
+	rfc4034Owner  = "dskey.example.com."
+	rfc4034DNSKEY = "256 3 5 AQOeiiR0GOMYkDshWoSKz9Xz fwJr1AYtsmx3TGkJaNXVbfi/ " +
+		"2pHm822aJ5iI9BMzNXxeYCmZ DRD99WYwYqUSdjMmmAphXdvx egXd/M5+X7OrzKBaMbCVdFLU " +
+		"Uh6DhweJBjEVv5f2wwjM9Xzc nOf+EPbtG9DMBmADjFDc2w/r ljwvFw=="
+
+	rfc8080Owner  = "example.com."
+	rfc8080DNSKEY = "257 3 15 l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4="
+)
+
+func TestParseDNSKEY(t *testing.T) {
+	tests := []struct {
+		name      string
+		rdata     string
+		keyTag    uint16
+		algorithm uint8
+		ksk       bool
+	}{
+		{name: "RFC 4034 RSA/SHA-1", rdata: rfc4034DNSKEY, keyTag: 60485, algorithm: 5, ksk: false},
+		{name: "RFC 8080 Ed25519", rdata: rfc8080DNSKEY, keyTag: 3613, algorithm: 15, ksk: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, _, err := parseDNSKEY(tt.rdata)
+			if err != nil {
+				t.Fatalf("parseDNSKEY: %v", err)
+			}
+			if key.keyTag != tt.keyTag {
+				t.Errorf("key tag = %d, want %d", key.keyTag, tt.keyTag)
+			}
+			if key.algorithm != tt.algorithm {
+				t.Errorf("algorithm = %d, want %d", key.algorithm, tt.algorithm)
+			}
+			if key.ksk != tt.ksk {
+				t.Errorf("ksk = %t, want %t", key.ksk, tt.ksk)
+			}
+		})
+	}
+}
+
+func TestParseDNSKEYInvalid(t *testing.T) {
+	for _, rdata := range []string{
+		"257 3 15",
+		"65536 3 15 AAAA",
+		"257 3 256 AAAA",
+		"257 3 15 not-base64!",
+	} {
+		if _, _, err := parseDNSKEY(rdata); err == nil {
+			t.Errorf("parseDNSKEY(%q) succeeded, want an error", rdata)
+		}
+	}
+}
+
+func TestDeriveDS(t *testing.T) {
+	tests := []struct {
+		name       string
+		owner      string
+		rdata      string
+		digestType uint8
+		data       string
+	}{
+		{
+			name:       "RFC 4509 SHA-256",
+			owner:      rfc4034Owner,
+			rdata:      rfc4034DNSKEY,
+			digestType: DigestSHA256,
+			data:       "60485 5 2 D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A",
+		},
+		{
+			name:       "RFC 8080 SHA-256",
+			owner:      rfc8080Owner,
+			rdata:      rfc8080DNSKEY,
+			digestType: DigestSHA256,
+			data:       "3613 15 2 3AA5AB37EFCE57F737FC1627013FEE07BDF241BD10F3B1964AB55C78E79A304B",
+		},
+		{
+			name:       "owner in upper case",
+			owner:      "EXAMPLE.com",
+			rdata:      rfc8080DNSKEY,
+			digestType: DigestSHA256,
+			data:       "3613 15 2 3AA5AB37EFCE57F737FC1627013FEE07BDF241BD10F3B1964AB55C78E79A304B",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, wire, err := parseDNSKEY(tt.rdata)
+			if err != nil {
+				t.Fatalf("parseDNSKEY: %v", err)
+			}
+			ds, err := deriveDS(tt.owner, key, wire, tt.digestType)
+			if err != nil {
+				t.Fatalf("deriveDS: %v", err)
+			}
+			if ds.Data != tt.data {
+				t.Errorf("DS = %q\nwant %q", ds.Data, tt.data)
+			}
+		})
+	}
+
+	key, wire, _ := parseDNSKEY(rfc8080DNSKEY)
+	if _, err := deriveDS(rfc8080Owner, key, wire, 1); err == nil {
+		t.Errorf("deriveDS with digest type 1 succeeded, want an error")
+	}
+}
+
+// TestDSDigestInput checks the digested data against the SHA-1 DS of RFC 4034
+// section 5.4. deriveDS does not offer SHA-1, but hashes the same input.
+func TestDSDigestInput(t *testing.T) {
+	_, wire, err := parseDNSKEY(rfc4034DNSKEY)
+	if err != nil {
+		t.Fatalf("parseDNSKEY: %v", err)
+	}
+
+	sum := sha1.Sum(append(nameWire(rfc4034Owner), wire...))
+	want := "2BB183AF5F22588179A53B0A98631FAD1A292118"
+	if got := strings.ToUpper(hex.EncodeToString(sum[:])); got != want {
+		t.Errorf("SHA-1 digest = %s, want %s", got, want)
+	}
+}
+
+func TestKeyTagRSAMD5(t *testing.T) {
+	// Algorithm 1 takes the key tag from the 2nd and 3rd last octets of the
+	// key data (RFC 4034, appendix B.1)
+	wire := []byte{0x01, 0x00, 0x03, 0x01, 0xaa, 0xbb, 0x12, 0x34, 0xcc}
+	if got := keyTag(wire); got != 0x1234 {
+		t.Errorf("keyTag = %#04x, want 0x1234", got)
+	}
+}
@@ -0,0 +1,133 @@
+package knot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Filters of zone-diff data units telling removed from added records
+const (
+	diffRemoved = "-"
+	diffAdded   = "+"
+)
+
+// DryRun receives the outcome of a write made under WithDryRun: the records
+// knotd would remove and add, and the result of its semantic checks
+type DryRun struct {
+	Removed []DNSRecord `json:"removed"`
+	Added   []DNSRecord `json:"added"`
+
+	// Check is nil when the write needed no transaction because nothing
+	// would change
+	Check *SemanticCheck `json:"semantic_check,omitempty"`
+}
+
+// SemanticCheck is the result of checking the zone contents a transaction
+// would commit for errors that knotd would only report when loading the
+// zone: a missing SOA or NS record at the apex, and CNAME records sharing
+// their owner with other data
+type SemanticCheck struct {
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// dryRunKey is the context key of the DryRun collecting a dry run
+type dryRunKey struct{}
+
+// WithDryRun returns a context under which writes validate their input and
+// apply it in a zone transaction as usual, but record the transaction's
+// diff and semantic check in dry and abort it instead of committing
+func WithDryRun(ctx context.Context, dry *DryRun) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, dry)
+}
+
+// dryRunFrom returns the DryRun of ctx, or nil outside of a dry run
+func dryRunFrom(ctx context.Context) *DryRun {
+	dry, _ := ctx.Value(dryRunKey{}).(*DryRun)
+	return dry
+}
+
+// dryRun fills in dry from the open transaction on cn: its zone-diff, and
+// the check of its contents read with zone-get. A failed check is part of
+// the result, not an error.
+func (c *Client) dryRun(ctx context.Context, cn conn, zone string, dry *DryRun) error {
+	dry.Removed, dry.Added = []DNSRecord{}, []DNSRecord{}
+
+	err := c.execute(ctx, cn, ctlData{ctlIdxCmd: "zone-diff", ctlIdxZone: zone}, func(data ctlData) error {
+		record, err := recordFromCtl(data)
+		if err != nil {
+			c.logger.Warnf("Failed to parse record: %v, error: %v", data[ctlIdxOwner:ctlIdxCount], err)
+			return nil
+		}
+		qualifyRecord(zone, record)
+
+		switch data[ctlIdxFilter] {
+		case diffRemoved:
+			dry.Removed = append(dry.Removed, *record)
+		case diffAdded:
+			dry.Added = append(dry.Added, *record)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to diff transaction for zone %s: %w", zone, err)
+	}
+
+	// knotd's zone-check checks the zone it would load from its zone file,
+	// not the transaction, so the contents to commit are checked here
+	types := make(map[string]map[RecordType]bool)
+	err = c.execute(ctx, cn, ctlData{ctlIdxCmd: "zone-get", ctlIdxZone: zone}, func(data ctlData) error {
+		owner := qualifyOwner(data[ctlIdxOwner], zone)
+		if types[owner] == nil {
+			types[owner] = make(map[RecordType]bool)
+		}
+		types[owner][RecordType(strings.ToUpper(data[ctlIdxType]))] = true
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read transaction for zone %s: %w", zone, err)
+	}
+	dry.Check = checkZone(zone, types)
+
+	return nil
+}
+
+// checkZone checks the record types at each owner of a zone, as read from a
+// transaction, and reports every problem found
+func checkZone(zone string, types map[string]map[RecordType]bool) *SemanticCheck {
+	apex := strings.ToLower(normalizeZoneName(zone))
+
+	var problems []string
+	if !types[apex][RecordTypeSOA] {
+		problems = append(problems, "missing SOA record at the zone apex")
+	}
+	if !types[apex][RecordTypeNS] {
+		problems = append(problems, "missing NS record at the zone apex")
+	}
+	for _, owner := range sortedKeys(types) {
+		if !types[owner][RecordTypeCNAME] {
+			continue
+		}
+		// DNSSEC records are the only data allowed next to a CNAME (RFC 4035)
+		for recordType := range types[owner] {
+			if recordType != RecordTypeCNAME && recordType != "RRSIG" && recordType != "NSEC" {
+				problems = append(problems, fmt.Sprintf("CNAME at %s has other records", owner))
+				break
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return &SemanticCheck{Passed: true}
+	}
+	return &SemanticCheck{Passed: false, Message: strings.Join(problems, "; ")}
+}
+
+// logWrite logs a finished write, marking writes made in a dry run
+func (c *Client) logWrite(ctx context.Context, format string, args ...any) {
+	if dryRunFrom(ctx) != nil {
+		format = "Dry run: " + format
+	}
+	c.logger.Infof(format, args...)
+}
@@ -0,0 +1,87 @@
+package knot
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCheckZone(t *testing.T) {
+	apex := map[RecordType]bool{RecordTypeSOA: true, RecordTypeNS: true}
+	tests := []struct {
+		name  string
+		types map[string]map[RecordType]bool
+		want  SemanticCheck
+	}{
+		{
+			name:  "valid",
+			types: map[string]map[RecordType]bool{"example.com.": apex, "www.example.com.": {RecordTypeA: true}},
+			want:  SemanticCheck{Passed: true},
+		},
+		{
+			name:  "signed CNAME",
+			types: map[string]map[RecordType]bool{"example.com.": apex, "www.example.com.": {RecordTypeCNAME: true, "RRSIG": true, "NSEC": true}},
+			want:  SemanticCheck{Passed: true},
+		},
+		{
+			name:  "missing apex records",
+			types: map[string]map[RecordType]bool{"www.example.com.": {RecordTypeA: true}},
+			want:  SemanticCheck{Message: "missing SOA record at the zone apex; missing NS record at the zone apex"},
+		},
+		{
+			name:  "CNAME with other data",
+			types: map[string]map[RecordType]bool{"example.com.": apex, "www.example.com.": {RecordTypeCNAME: true, RecordTypeA: true}},
+			want:  SemanticCheck{Message: "CNAME at www.example.com. has other records"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkZone("example.com", tt.types); *got != tt.want {
+				t.Errorf("checkZone = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDryRunChecksTransaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []Change
+		check   SemanticCheck
+	}{
+		{
+			name:    "valid change",
+			changes: []Change{{Op: ChangeOpAdd, Name: "mail", Type: RecordTypeA, TTL: 300, Values: []string{"192.0.2.2"}}},
+			check:   SemanticCheck{Passed: true},
+		},
+		{
+			name:    "CNAME next to an A record",
+			changes: []Change{{Op: ChangeOpAdd, Name: "www", Type: RecordTypeCNAME, TTL: 300, Values: []string{"old.example.com."}}},
+			check:   SemanticCheck{Message: "CNAME at www.example.com. has other records"},
+		},
+		{
+			name:    "apex NS removed",
+			changes: []Change{{Op: ChangeOpRemove, Name: "@", Type: RecordTypeNS}},
+			check:   SemanticCheck{Message: "missing NS record at the zone apex"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t)
+			before := zoneRecords(t, c)
+
+			dry := &DryRun{}
+			if err := c.ApplyChanges(WithDryRun(context.Background(), dry), "example.com", tt.changes); err != nil {
+				t.Fatalf("ApplyChanges: %v", err)
+			}
+			if dry.Check == nil || *dry.Check != tt.check {
+				t.Errorf("check = %+v, want %+v", dry.Check, tt.check)
+			}
+			if got := zoneRecords(t, c); !reflect.DeepEqual(got, before) {
+				t.Errorf("dry run changed the zone: %q", got)
+			}
+		})
+	}
+}
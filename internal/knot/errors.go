@@ -0,0 +1,111 @@
+package knot
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failures, matched with errors.Is. Every error returned by Client
+// that is not an internal failure wraps one of them through an *Error.
+var (
+	ErrNotAllowed  = errors.New("not allowed")
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrInvalid     = errors.New("invalid")
+	ErrUnavailable = errors.New("knotd unavailable")
+)
+
+// Error is a failure of one of the kinds above. Code identifies the
+// failure for API clients and does not change between releases; Field names
+// the request field at fault for ErrInvalid.
+type Error struct {
+	Kind  error
+	Code  string
+	Field string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap makes both the kind and the underlying error visible to errors.Is
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// newError returns an *Error with a fixed message, for use as a sentinel
+// that callers wrap with details
+func newError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Err: errors.New(message)}
+}
+
+// Sentinels for specific failures; wrap them with fmt.Errorf("%w: ...")
+var (
+	ErrZoneNotAllowed  = newError(ErrNotAllowed, "zone_not_allowed", "zone not allowed")
+	ErrZoneNotFound    = newError(ErrNotFound, "zone_not_found", "zone not found")
+	ErrRecordNotFound  = newError(ErrNotFound, "record_not_found", "record not found")
+	ErrZoneExists      = newError(ErrConflict, "zone_exists", "zone already exists")
+	ErrAmbiguousUpdate = newError(ErrConflict, "ambiguous_update", "ambiguous update")
+	ErrChangeConflict  = newError(ErrConflict, "change_conflict", "record changed since it was read")
+	ErrNotSupported    = newError(ErrConflict, "not_supported", "operation not supported")
+
+	// ErrTransactionBusy is returned when a zone transaction cannot be opened
+	// because another one, usually started outside hyprknot, is still open
+	ErrTransactionBusy = newError(ErrConflict, "transaction_busy", "zone transaction already open")
+
+	// ErrSecondaryZone is returned when changing a zone that this server
+	// receives from a primary by zone transfer
+	ErrSecondaryZone = newError(ErrConflict, "secondary_zone", "zone is a secondary, change it on its primary")
+
+	// ErrPlanStale is returned when applying a sync plan whose fingerprint no
+	// longer matches the zone, because it was changed since planning
+	ErrPlanStale = newError(ErrConflict, "plan_stale", "zone changed since the plan was made")
+
+	// ErrZoneTemplateIncomplete is returned when creating a zone while the
+	// zone template lacks what the initial zone contents need
+	ErrZoneTemplateIncomplete = newError(ErrUnavailable, "zone_template_incomplete",
+		"zone template has no name servers, set knot.zone_template.nameservers")
+)
+
+// invalidField marks err as a validation failure of a request field
+func invalidField(field string, err error) error {
+	return &Error{Kind: ErrInvalid, Code: "validation_failed", Field: field, Err: err}
+}
+
+// invalidFieldf is invalidField with a formatted message
+func invalidFieldf(field, format string, args ...any) error {
+	return invalidField(field, fmt.Errorf(format, args...))
+}
+
+// invalidChange marks err as a validation failure of the change at index i
+// of a change set, pointing its field into the list of changes
+func invalidChange(i int, err error) error {
+	return invalidItem("changes", i, fmt.Errorf("invalid change %d: %w", i, err))
+}
+
+// invalidItem marks err as a validation failure of the element at index i
+// of the request list named list, pointing its field into that element
+func invalidItem(list string, i int, err error) error {
+	field := fmt.Sprintf("%s[%d]", list, i)
+	var kerr *Error
+	if errors.As(err, &kerr) && kerr.Field != "" {
+		field += "." + kerr.Field
+	}
+	return invalidField(field, err)
+}
+
+// codeBackendUnavailable is the code of failures to reach knotd
+const codeBackendUnavailable = "backend_unavailable"
+
+// unavailable marks err as a failure to reach knotd
+func unavailable(err error) error {
+	return &Error{Kind: ErrUnavailable, Code: codeBackendUnavailable, Err: err}
+}
+
+// IsBackendUnavailable reports whether err is a failure to reach knotd, as
+// opposed to another kind of ErrUnavailable
+func IsBackendUnavailable(err error) bool {
+	var kerr *Error
+	return errors.As(err, &kerr) && kerr.Code == codeBackendUnavailable
+}
@@ -0,0 +1,95 @@
+package knot
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultExportTTL is the $TTL of an exported zone without SOA
+const defaultExportTTL = 3600
+
+// ExportZone returns every record of a zone as read with zone-read, the SOA
+// first and the rest in canonical order (RFC 4034, section 6.1)
+func (c *Client) ExportZone(ctx context.Context, zone string) (*Zone, error) {
+	records, err := c.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	sortRecords(records)
+	return &Zone{Name: normalizeZoneName(zone), Records: records}, nil
+}
+
+// sortRecords orders records by owner in canonical order and then by type,
+// keeping the SOA in front. Values of an RRset keep their order.
+func sortRecords(records []DNSRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := &records[i], &records[j]
+		if (a.Type == RecordTypeSOA) != (b.Type == RecordTypeSOA) {
+			return a.Type == RecordTypeSOA
+		}
+		if a.FQDN != b.FQDN {
+			return canonicalLess(a.FQDN, b.FQDN)
+		}
+		return a.Type < b.Type
+	})
+}
+
+// canonicalLess reports whether the absolute name a sorts before b, comparing
+// labels from the right
+func canonicalLess(a, b string) bool {
+	la := strings.Split(strings.TrimSuffix(a, "."), ".")
+	lb := strings.Split(strings.TrimSuffix(b, "."), ".")
+	for i := 1; i <= len(la) && i <= len(lb); i++ {
+		x, y := la[len(la)-i], lb[len(lb)-i]
+		if x != y {
+			return x < y
+		}
+	}
+	return len(la) < len(lb)
+}
+
+// DefaultTTL returns the TTL written as $TTL of the zone file: the TTL of
+// the SOA, or one hour for a zone without SOA
+func (z *Zone) DefaultTTL() uint32 {
+	for _, record := range z.Records {
+		if record.Type == RecordTypeSOA {
+			return record.TTL
+		}
+	}
+	return defaultExportTTL
+}
+
+// WriteMasterFile writes the zone as an RFC 1035 master file with $ORIGIN
+// and $TTL directives. Owners are relative to the origin and TTLs equal to
+// $TTL are left out.
+func (z *Zone) WriteMasterFile(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	ttl := z.DefaultTTL()
+
+	fmt.Fprintf(bw, "; Zone %s exported by hyprknot\n", z.Name)
+	fmt.Fprintf(bw, "$ORIGIN %s\n", z.Name)
+	fmt.Fprintf(bw, "$TTL %d\n", ttl)
+
+	width := 1
+	for _, record := range z.Records {
+		if len(record.Name) > width {
+			width = len(record.Name)
+		}
+	}
+
+	for _, record := range z.Records {
+		recordTTL := ""
+		if record.TTL != ttl {
+			recordTTL = strconv.FormatUint(uint64(record.TTL), 10)
+		}
+		fmt.Fprintf(bw, "%-*s %-6s IN %-6s %s\n", width, record.Name, recordTTL, record.Type, record.RData())
+	}
+
+	return bw.Flush()
+}
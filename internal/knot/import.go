@@ -0,0 +1,189 @@
+package knot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Import modes
+const (
+	ImportModeMerge   = "merge"   // add and replace the RRsets in the file
+	ImportModeReplace = "replace" // also remove the RRsets not in the file
+)
+
+// ImportResult counts the RRsets changed by an import
+type ImportResult struct {
+	Zone      string `json:"zone"`
+	Mode      string `json:"mode"`
+	Added     int    `json:"added"`
+	Replaced  int    `json:"replaced"`
+	Removed   int    `json:"removed"`
+	Unchanged int    `json:"unchanged"`
+	Skipped   int    `json:"skipped"` // DNSSEC records left to the signer
+}
+
+// ImportZone applies a zone file to a zone in a single transaction. In
+// merge mode the RRsets in the file are added or replace the existing
+// ones; in replace mode every other RRset is removed as well. The SOA
+// serial is kept if it moves the zone forward and is advanced by the serial
+// policy otherwise. Records maintained by the DNSSEC signer are skipped.
+func (c *Client) ImportZone(ctx context.Context, zone string, zoneFile io.Reader, mode string) (*ImportResult, error) {
+	if !c.IsZoneAllowed(zone) {
+		return nil, fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+	if mode != ImportModeMerge && mode != ImportModeReplace {
+		return nil, invalidFieldf("mode", "invalid import mode: %s", mode)
+	}
+
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	apex := strings.ToLower(normalizeZoneName(zone))
+	entries, err := parseZoneFile(zoneFile, apex)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Zone: apex, Mode: mode}
+	desired, soa, err := c.importRRSets(zone, entries, result)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := c.FindRecords(ctx, zone, "", "")
+	if err != nil {
+		return nil, err
+	}
+	current := groupRRSets(records)
+
+	diff := diffRRSets(current, desired, mode == ImportModeReplace)
+	result.Added = len(diff.Adds)
+	result.Replaced = len(diff.Changes)
+	result.Removed = len(diff.Removes)
+	result.Unchanged = diff.Unchanged
+
+	changes := diff.toChanges()
+	if soa != nil {
+		change, err := c.importSOA(zone, soa, current)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	if len(changes) == 0 {
+		c.logWrite(ctx, "Imported zone file into zone %s, nothing changed", zone)
+		return result, nil
+	}
+
+	err = c.transaction(ctx, zone, func(t *txn) error {
+		for _, change := range changes {
+			if err := t.apply(change); err != nil {
+				return fmt.Errorf("failed to import %s %s into zone %s: %w", change.Name, change.Type, zone, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.logWrite(ctx, "Imported zone file into zone %s (%s): %d added, %d replaced, %d removed",
+		zone, mode, result.Added, result.Replaced, result.Removed)
+	return result, nil
+}
+
+// importRRSets groups and validates the records of a zone file. The SOA is
+// returned on its own.
+func (c *Client) importRRSets(zone string, entries []zoneFileEntry, result *ImportResult) (map[rrsetKey]*RRSet, *zoneFileEntry, error) {
+	rrsets := make(map[rrsetKey]*RRSet)
+	var soa *zoneFileEntry
+
+	for i := range entries {
+		entry := &entries[i]
+		lineErr := func(err error) error {
+			return invalidField(zoneFileField, fmt.Errorf("line %d: %w", entry.line, err))
+		}
+
+		owner, err := ownerName(zone, entry.owner)
+		if err != nil {
+			return nil, nil, lineErr(err)
+		}
+
+		switch {
+		case signerTypes[entry.typ]:
+			result.Skipped++
+			continue
+		case entry.typ == RecordTypeSOA:
+			if owner != strings.ToLower(normalizeZoneName(zone)) {
+				return nil, nil, lineErr(fmt.Errorf("SOA must be at the zone apex"))
+			}
+			soa = entry
+			continue
+		case !IsValidRecordType(string(entry.typ)):
+			return nil, nil, lineErr(fmt.Errorf("unsupported record type: %s", entry.typ))
+		}
+
+		key := rrsetKey{owner: owner, recordType: entry.typ}
+		rrset, ok := rrsets[key]
+		if !ok {
+			rrset = &RRSet{Type: entry.typ, TTL: entry.ttl}
+			qualifyRRSet(zone, owner, rrset)
+			rrsets[key] = rrset
+		}
+		value := &RRSet{Name: owner, Type: entry.typ, TTL: entry.ttl, Values: []string{entry.rdata}}
+		if err := value.Validate(); err != nil {
+			return nil, nil, lineErr(err)
+		}
+		// All records of an RRset share the TTL of the first one (RFC 2181)
+		if !containsValue(rrset.Values, value.Values[0]) {
+			rrset.Values = append(rrset.Values, value.Values[0])
+		}
+	}
+
+	return rrsets, soa, nil
+}
+
+// importSOA returns the change that installs the SOA of a zone file with a
+// serial that moves the zone forward, or nil if only the serial differs
+func (c *Client) importSOA(zone string, entry *zoneFileEntry, current map[rrsetKey]*RRSet) (*Change, error) {
+	lineErr := func(err error) error {
+		return invalidField(zoneFileField, fmt.Errorf("line %d: %w", entry.line, err))
+	}
+
+	soa, err := parseSOA(entry.rdata, entry.ttl)
+	if err != nil {
+		return nil, lineErr(err)
+	}
+	if err := soa.Validate(); err != nil {
+		return nil, lineErr(err)
+	}
+
+	apex := strings.ToLower(normalizeZoneName(zone))
+	if existing := current[rrsetKey{owner: apex, recordType: RecordTypeSOA}]; existing != nil {
+		old, err := parseSOA(existing.Values[0], existing.TTL)
+		if err != nil {
+			return nil, err
+		}
+
+		policy := c.serialPolicy(zone)
+		if validateSerial(policy, soa.Serial, old.Serial) != nil {
+			soa.Serial = nextSerial(policy, old.Serial, time.Now())
+		}
+
+		unchanged := *soa
+		unchanged.Serial = old.Serial
+		if unchanged.RData() == old.RData() && soa.TTL == old.TTL {
+			return nil, nil
+		}
+	}
+
+	return &Change{Op: ChangeOpReplace, Name: apex, Type: RecordTypeSOA, TTL: soa.TTL, Values: []string{soa.RData()}}, nil
+}
@@ -0,0 +1,155 @@
+package knot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newTestClient returns a client of an in-memory backend holding
+// example.com, with www and old A records besides the apex SOA and NS
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	c := NewMemoryBackend([]string{"example.com"}, Options{}, logger).(*Client)
+
+	for _, record := range []DNSRecord{
+		{Name: "www", Type: RecordTypeA, TTL: 300, Data: "192.0.2.1"},
+		{Name: "old", Type: RecordTypeA, TTL: 300, Data: "192.0.2.9"},
+	} {
+		if err := c.CreateRecord(context.Background(), "example.com", &record); err != nil {
+			t.Fatalf("CreateRecord: %v", err)
+		}
+	}
+	return c
+}
+
+// zoneRecords returns the records of example.com but the SOA, sorted, as
+// "fqdn ttl type data"
+func zoneRecords(t *testing.T, c *Client) []string {
+	t.Helper()
+
+	records, err := c.FindRecords(context.Background(), "example.com", "", "")
+	if err != nil {
+		t.Fatalf("FindRecords: %v", err)
+	}
+	var lines []string
+	for _, record := range records {
+		if record.Type != RecordTypeSOA {
+			lines = append(lines, fmt.Sprintf("%s %d %s %s", record.FQDN, record.TTL, record.Type, record.Data))
+		}
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+func TestImportZone(t *testing.T) {
+	zoneFile := `$TTL 600
+@	NS	ns1
+www	A	192.0.2.2
+new	300	A	192.0.2.3
+	A	192.0.2.4
+@	DNSKEY	257 3 15 l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4=
+`
+
+	tests := []struct {
+		mode    string
+		result  ImportResult
+		records []string
+	}{
+		{
+			mode:   ImportModeMerge,
+			result: ImportResult{Zone: "example.com.", Mode: ImportModeMerge, Added: 1, Replaced: 2, Skipped: 1},
+			records: []string{
+				"example.com. 600 NS ns1.example.com.",
+				"new.example.com. 300 A 192.0.2.3",
+				"new.example.com. 300 A 192.0.2.4",
+				"old.example.com. 300 A 192.0.2.9",
+				"www.example.com. 600 A 192.0.2.2",
+			},
+		},
+		{
+			mode:   ImportModeReplace,
+			result: ImportResult{Zone: "example.com.", Mode: ImportModeReplace, Added: 1, Replaced: 2, Removed: 1, Skipped: 1},
+			records: []string{
+				"example.com. 600 NS ns1.example.com.",
+				"new.example.com. 300 A 192.0.2.3",
+				"new.example.com. 300 A 192.0.2.4",
+				"www.example.com. 600 A 192.0.2.2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			c := newTestClient(t)
+
+			result, err := c.ImportZone(context.Background(), "example.com", strings.NewReader(zoneFile), tt.mode)
+			if err != nil {
+				t.Fatalf("ImportZone: %v", err)
+			}
+			if *result != tt.result {
+				t.Errorf("result = %+v\nwant %+v", *result, tt.result)
+			}
+			if got := zoneRecords(t, c); !reflect.DeepEqual(got, tt.records) {
+				t.Errorf("records = %q\nwant %q", got, tt.records)
+			}
+		})
+	}
+}
+
+func TestImportZoneUnchanged(t *testing.T) {
+	c := newTestClient(t)
+	before := zoneRecords(t, c)
+
+	zoneFile := "www 300 A 192.0.2.1\nold.example.com. 300 IN A 192.0.2.9\n"
+	result, err := c.ImportZone(context.Background(), "example.com", strings.NewReader(zoneFile), ImportModeMerge)
+	if err != nil {
+		t.Fatalf("ImportZone: %v", err)
+	}
+	if result.Unchanged != 2 || result.Added+result.Replaced+result.Removed != 0 {
+		t.Errorf("result = %+v, want 2 unchanged RRsets", *result)
+	}
+	if got := zoneRecords(t, c); !reflect.DeepEqual(got, before) {
+		t.Errorf("records = %q\nwant %q", got, before)
+	}
+}
+
+func TestImportZoneInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		zoneFile string
+		field    string
+	}{
+		{name: "mode", mode: "append", zoneFile: "www 300 A 192.0.2.2\n", field: "mode"},
+		{name: "record data", mode: ImportModeMerge, zoneFile: "www 300 A not-an-address\n", field: zoneFileField},
+		{name: "owner outside zone", mode: ImportModeMerge, zoneFile: "www.example.net. 300 A 192.0.2.2\n", field: zoneFileField},
+		{name: "SOA below apex", mode: ImportModeMerge, zoneFile: "www 300 SOA ns1 host 1 2 3 4 5\n", field: zoneFileField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t)
+			before := zoneRecords(t, c)
+
+			_, err := c.ImportZone(context.Background(), "example.com", strings.NewReader(tt.zoneFile), tt.mode)
+			var kerr *Error
+			if !errors.As(err, &kerr) || kerr.Kind != ErrInvalid || kerr.Field != tt.field {
+				t.Errorf("ImportZone error = %v, want an invalid %s error", err, tt.field)
+			}
+			if got := zoneRecords(t, c); !reflect.DeepEqual(got, before) {
+				t.Errorf("records = %q\nwant %q", got, before)
+			}
+		})
+	}
+}
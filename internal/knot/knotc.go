@@ -0,0 +1,183 @@
+package knot
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// knotcConn is the fallback transport that forks knotc for every command
+type knotcConn struct {
+	knotcPath  string
+	socketPath string
+}
+
+// confLineRe matches knotc configuration output: section[id].item = data
+var confLineRe = regexp.MustCompile(`^([a-z0-9-]+)(?:\[([^\]]*)\])?(?:\.([a-z0-9-]+))?(?:\s*=\s*(.*))?$`)
+
+// exec runs knotc with arguments derived from req and parses its output.
+// knotc is killed once ctx is done.
+func (k *knotcConn) exec(ctx context.Context, req ctlData, fn func(ctlData) error) error {
+	cmd := exec.CommandContext(ctx, k.knotcPath, k.args(req)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("knotc %s interrupted: %w", req[ctlIdxCmd], ctx.Err())
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return unavailable(fmt.Errorf("failed to run knotc: %w", err))
+	}
+	if err != nil {
+		return fmt.Errorf("knotc command failed: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	if fn == nil {
+		return nil
+	}
+	for _, data := range parseKnotcOutput(req[ctlIdxCmd], string(output)) {
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// close is a no-op since every knotc invocation is its own session
+func (k *knotcConn) close() error {
+	return nil
+}
+
+// args converts a control request into knotc command line arguments
+func (k *knotcConn) args(req ctlData) []string {
+	var args []string
+	if k.socketPath != "" {
+		args = append(args, "-s", k.socketPath)
+	}
+	for _, flag := range req[ctlIdxFlags] {
+		switch flag {
+		case 'F':
+			args = append(args, "-f")
+		case 'B':
+			args = append(args, "-b")
+		}
+	}
+
+	cmd := req[ctlIdxCmd]
+	args = append(args, cmd)
+
+	// Configuration commands address items as section[id].item
+	if strings.HasPrefix(cmd, "conf-") {
+		if req[ctlIdxSection] != "" {
+			item := req[ctlIdxSection]
+			if req[ctlIdxID] != "" {
+				item += "[" + req[ctlIdxID] + "]"
+			}
+			if req[ctlIdxItem] != "" {
+				item += "." + req[ctlIdxItem]
+			}
+			args = append(args, item)
+		}
+		if req[ctlIdxData] != "" {
+			args = append(args, req[ctlIdxData])
+		}
+		return args
+	}
+
+	for _, idx := range []ctlIdx{ctlIdxZone, ctlIdxOwner, ctlIdxTTL, ctlIdxType, ctlIdxData} {
+		if req[idx] != "" {
+			args = append(args, req[idx])
+		}
+	}
+
+	return args
+}
+
+// parseKnotcOutput converts knotc text output into control data units
+func parseKnotcOutput(cmd, output string) []ctlData {
+	var result []ctlData
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var data ctlData
+		switch {
+		case strings.HasPrefix(cmd, "conf-"):
+			match := confLineRe.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			data[ctlIdxSection] = match[1]
+			data[ctlIdxID] = match[2]
+			data[ctlIdxItem] = match[3]
+			data[ctlIdxData] = match[4]
+		case cmd == "zone-read" || cmd == "zone-get":
+			zone, owner, ttl, rtype, rdata, err := splitKnotRecord(line)
+			if err != nil {
+				continue
+			}
+			data[ctlIdxZone] = zone
+			data[ctlIdxOwner] = owner
+			data[ctlIdxTTL] = ttl
+			data[ctlIdxType] = rtype
+			data[ctlIdxData] = rdata
+		case cmd == "zone-diff":
+			// Removed and added records: [zone] -owner ttl type data
+			zone, owner, ttl, rtype, rdata, err := splitKnotRecord(line)
+			if err != nil || (owner[0] != '-' && owner[0] != '+') {
+				continue
+			}
+			data[ctlIdxZone] = zone
+			data[ctlIdxFilter] = owner[:1]
+			data[ctlIdxOwner] = owner[1:]
+			data[ctlIdxTTL] = ttl
+			data[ctlIdxType] = rtype
+			data[ctlIdxData] = rdata
+		case cmd == "stats" || cmd == "zone-stats":
+			match := statLineRe.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			data[ctlIdxZone] = match[1]
+			data[ctlIdxSection] = match[2]
+			data[ctlIdxItem] = match[3]
+			data[ctlIdxID] = match[4]
+			data[ctlIdxData] = match[5]
+		case cmd == "zone-status":
+			// One line per zone: [zone] role: master | serial: 1 | ...
+			zone, rest := splitZonePrefix(line)
+			for _, item := range splitStatusItems(rest) {
+				var unit ctlData
+				unit[ctlIdxZone] = zone
+				unit[ctlIdxType] = item[0]
+				unit[ctlIdxData] = item[1]
+				result = append(result, unit)
+			}
+			continue
+		default:
+			data[ctlIdxZone], data[ctlIdxData] = splitZonePrefix(line)
+		}
+
+		result = append(result, data)
+	}
+
+	return result
+}
+
+// splitZonePrefix splits a "[zone] rest" output line into its parts
+func splitZonePrefix(line string) (string, string) {
+	if strings.HasPrefix(line, "[") {
+		if end := strings.Index(line, "]"); end != -1 {
+			return line[1:end], strings.TrimSpace(line[end+1:])
+		}
+	}
+	return "", line
+}
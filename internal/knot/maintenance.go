@@ -0,0 +1,92 @@
+package knot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MaintenanceRequest represents a request to turn zone maintenance on or off
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// FreezeZone postpones zone events such as refresh and signing. A frozen
+// zone is in maintenance, so the API stops accepting changes to it.
+func (c *Client) FreezeZone(ctx context.Context, zone string) error {
+	return c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-freeze"})
+}
+
+// ThawZone resumes zone events and ends maintenance of the zone
+func (c *Client) ThawZone(ctx context.Context, zone string) error {
+	if err := c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-thaw"}); err != nil {
+		return err
+	}
+	return c.SetMaintenance(zone, false)
+}
+
+// FlushZone writes the zone contents to its zone file
+func (c *Client) FlushZone(ctx context.Context, zone string) error {
+	return c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-flush"})
+}
+
+// PurgeZone removes the zone file, journal, timers and DNSSEC keys of a zone
+// while keeping it configured
+func (c *Client) PurgeZone(ctx context.Context, zone string) error {
+	if !c.IsZoneAllowed(zone) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-purge", ctlIdxFlags: "F"})
+}
+
+// SetMaintenance turns maintenance of a zone on or off without freezing it
+// in knotd. The setting is kept in memory only; zones frozen in knotd are in
+// maintenance regardless.
+func (c *Client) SetMaintenance(zone string, enabled bool) error {
+	if !c.IsZoneAllowed(zone) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	key := strings.ToLower(normalizeZoneName(zone))
+
+	c.maintenanceMu.Lock()
+	if enabled {
+		c.maintenance[key] = true
+	} else {
+		delete(c.maintenance, key)
+	}
+	c.maintenanceMu.Unlock()
+
+	c.logger.Infof("Maintenance of zone %s: %t", zone, enabled)
+	return nil
+}
+
+// InMaintenance reports whether a zone is in maintenance, either turned on
+// by SetMaintenance or frozen in knotd, also by knotc or before a restart
+func (c *Client) InMaintenance(ctx context.Context, zone string) (bool, error) {
+	if c.maintenanceSet(zone) {
+		return true, nil
+	}
+
+	status, err := c.GetZoneStatus(ctx, zone)
+	if err != nil {
+		return false, err
+	}
+	return status.Maintenance, nil
+}
+
+// maintenanceSet reports whether SetMaintenance turned on maintenance of a zone
+func (c *Client) maintenanceSet(zone string) bool {
+	key := strings.ToLower(normalizeZoneName(zone))
+
+	c.maintenanceMu.RLock()
+	defer c.maintenanceMu.RUnlock()
+	return c.maintenance[key]
+}
@@ -0,0 +1,50 @@
+package knot
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestInMaintenanceFollowsFreeze(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	if err := c.FreezeZone(ctx, "example.com"); err != nil {
+		t.Fatalf("FreezeZone: %v", err)
+	}
+
+	// A client that did not freeze the zone, like hyprknot after a restart
+	// or with the zone frozen by knotc
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	restarted := newClient(Options{}, c.dial, logger)
+
+	for _, tt := range []struct {
+		step string
+		run  func() error
+		want bool
+	}{
+		{step: "frozen", run: func() error { return nil }, want: true},
+		{step: "thawed", run: func() error { return restarted.ThawZone(ctx, "example.com") }, want: false},
+		{step: "maintenance set", run: func() error { return restarted.SetMaintenance("example.com", true) }, want: true},
+		{step: "thawed again", run: func() error { return restarted.ThawZone(ctx, "example.com") }, want: false},
+	} {
+		if err := tt.run(); err != nil {
+			t.Fatalf("%s: %v", tt.step, err)
+		}
+		maintenance, err := restarted.InMaintenance(ctx, "example.com")
+		if err != nil {
+			t.Fatalf("%s: InMaintenance: %v", tt.step, err)
+		}
+		status, err := restarted.GetZoneStatus(ctx, "example.com")
+		if err != nil {
+			t.Fatalf("%s: GetZoneStatus: %v", tt.step, err)
+		}
+		if maintenance != tt.want || status.Maintenance != tt.want {
+			t.Errorf("%s: InMaintenance = %t, status maintenance = %t, want %t", tt.step, maintenance, status.Maintenance, tt.want)
+		}
+	}
+}
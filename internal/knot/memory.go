@@ -0,0 +1,478 @@
+package knot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// memoryRRset holds the TTL and record data of a single RRset
+type memoryRRset struct {
+	ttl   uint32
+	rdata []string
+}
+
+// memoryZone maps owner names to the RRsets stored at them
+type memoryZone map[string]map[RecordType]*memoryRRset
+
+// clone returns a deep copy of the zone contents
+func (z memoryZone) clone() memoryZone {
+	out := make(memoryZone, len(z))
+	for owner, rrsets := range z {
+		out[owner] = make(map[RecordType]*memoryRRset, len(rrsets))
+		for recordType, rrset := range rrsets {
+			out[owner][recordType] = &memoryRRset{
+				ttl:   rrset.ttl,
+				rdata: append([]string(nil), rrset.rdata...),
+			}
+		}
+	}
+	return out
+}
+
+// memoryStore emulates the zone and transaction handling of knotd's
+// control interface. Like knotd, it allows one open transaction per zone
+// regardless of which connection opened it.
+type memoryStore struct {
+	mu    sync.Mutex
+	zones map[string]memoryZone
+	txns  map[string]memoryZone
+
+	// confTxn holds the zone list of an open configuration transaction
+	confTxn map[string]bool
+
+	frozen map[string]bool
+}
+
+// NewMemoryBackend creates a backend that keeps the given zones in memory.
+// Every zone starts out with a SOA and NS record; nothing is persisted.
+func NewMemoryBackend(zones []string, opts Options, logger *logrus.Logger) Backend {
+	store := &memoryStore{
+		zones:  make(map[string]memoryZone),
+		txns:   make(map[string]memoryZone),
+		frozen: make(map[string]bool),
+	}
+	for _, zone := range zones {
+		store.addZone(normalizeZoneName(strings.ToLower(zone)))
+	}
+
+	return newClient(opts, func(ctx context.Context) (conn, error) {
+		return &memoryConn{store: store}, nil
+	}, logger)
+}
+
+// addZone creates an empty zone with default apex records
+func (s *memoryStore) addZone(zone string) {
+	serial := time.Now().UTC().Format("20060102") + "01"
+	s.zones[zone] = memoryZone{
+		zone: {
+			RecordTypeSOA: {ttl: 3600, rdata: []string{
+				fmt.Sprintf("ns1.%s hostmaster.%s %s 3600 900 604800 300", zone, zone, serial),
+			}},
+			RecordTypeNS: {ttl: 3600, rdata: []string{"ns1." + zone}},
+		},
+	}
+}
+
+// status returns zone-status data units for zone. In-memory zones are
+// always masters and have no scheduled events.
+func (s *memoryStore) status(zone string) []ctlData {
+	serial := "none"
+	if soa := s.zones[zone][zone][RecordTypeSOA]; soa != nil && len(soa.rdata) > 0 {
+		if fields := strings.Fields(soa.rdata[0]); len(fields) > 2 {
+			serial = fields[2]
+		}
+	}
+	transaction := "none"
+	if s.txns[zone] != nil {
+		transaction = "open"
+	}
+	freeze := "no"
+	if s.frozen[zone] {
+		freeze = "yes"
+	}
+
+	var rows []ctlData
+	for _, item := range [][2]string{
+		{"role", "master"},
+		{"serial", serial},
+		{"transaction", transaction},
+		{"freeze", freeze},
+	} {
+		rows = append(rows, ctlData{ctlIdxZone: zone, ctlIdxType: item[0], ctlIdxData: item[1]})
+	}
+	return rows
+}
+
+// memoryConn is a control session against a memoryStore
+type memoryConn struct {
+	store *memoryStore
+}
+
+// exec executes a control command against the in-memory store
+func (m *memoryConn) exec(ctx context.Context, req ctlData, fn func(ctlData) error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s interrupted: %w", req[ctlIdxCmd], err)
+	}
+
+	m.store.mu.Lock()
+	rows, err := m.store.handle(req)
+	m.store.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("%s failed: %s", req[ctlIdxCmd], err)
+	}
+
+	if fn == nil {
+		return nil
+	}
+	for _, data := range rows {
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// close is a no-op for in-memory sessions
+func (m *memoryConn) close() error {
+	return nil
+}
+
+// handle dispatches a single control command. The caller holds s.mu.
+func (s *memoryStore) handle(req ctlData) ([]ctlData, error) {
+	cmd := req[ctlIdxCmd]
+	if cmd == "status" {
+		return nil, nil
+	}
+	if strings.HasPrefix(cmd, "conf-") {
+		return s.handleConf(req)
+	}
+	if cmd == "stats" {
+		return []ctlData{{
+			ctlIdxSection: "server",
+			ctlIdxItem:    "zone-count",
+			ctlIdxData:    strconv.Itoa(len(s.zones)),
+		}}, nil
+	}
+	if cmd == "zone-stats" && req[ctlIdxZone] == "" {
+		// In-memory zones answer no queries
+		return nil, nil
+	}
+	if cmd == "zone-status" && req[ctlIdxZone] == "" {
+		var rows []ctlData
+		for _, zone := range sortedKeys(s.zones) {
+			rows = append(rows, s.status(zone)...)
+		}
+		return rows, nil
+	}
+
+	zone := normalizeZoneName(strings.ToLower(req[ctlIdxZone]))
+	contents, ok := s.zones[zone]
+	if !ok {
+		return nil, errors.New("no such zone found")
+	}
+	txn := s.txns[zone]
+
+	switch cmd {
+	case "zone-reload", "zone-flush", "zone-notify":
+		return nil, nil
+	case "zone-freeze":
+		s.frozen[zone] = true
+		return nil, nil
+	case "zone-thaw":
+		delete(s.frozen, zone)
+		return nil, nil
+	case "zone-purge":
+		s.zones[zone] = memoryZone{}
+		delete(s.txns, zone)
+		return nil, nil
+	case "zone-sign", "zone-key-rollover", "zone-ksk-submitted", "zone-refresh", "zone-retransfer":
+		// In-memory zones are unsigned primaries
+		return nil, errors.New("operation not supported")
+	case "zone-read":
+		return readMemoryZone(zone, contents, req), nil
+	case "zone-status":
+		return s.status(zone), nil
+	case "zone-begin":
+		if txn != nil {
+			return nil, errors.New("too many transactions")
+		}
+		s.txns[zone] = contents.clone()
+		return nil, nil
+	}
+
+	// The remaining commands operate on an open transaction
+	if txn == nil {
+		return nil, errors.New("no active transaction")
+	}
+
+	switch cmd {
+	case "zone-get":
+		return readMemoryZone(zone, txn, req), nil
+	case "zone-set":
+		return nil, txn.set(qualifyOwner(req[ctlIdxOwner], zone), req)
+	case "zone-unset":
+		return nil, txn.unset(qualifyOwner(req[ctlIdxOwner], zone), req)
+	case "zone-diff":
+		return diffMemoryZone(zone, contents, txn), nil
+	case "zone-commit":
+		s.zones[zone] = txn
+		delete(s.txns, zone)
+		return nil, nil
+	case "zone-abort":
+		delete(s.txns, zone)
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unsupported command")
+}
+
+// handleConf emulates the configuration commands for the zone section.
+// Only adding and removing zones is supported; other items are accepted
+// and ignored.
+func (s *memoryStore) handleConf(req ctlData) ([]ctlData, error) {
+	cmd := req[ctlIdxCmd]
+	switch cmd {
+	case "conf-read":
+		var rows []ctlData
+		for _, zone := range sortedKeys(s.zones) {
+			rows = append(rows, ctlData{ctlIdxSection: "zone", ctlIdxID: zone})
+		}
+		return rows, nil
+	case "conf-begin":
+		if s.confTxn != nil {
+			return nil, errors.New("too many transactions")
+		}
+		s.confTxn = make(map[string]bool, len(s.zones))
+		for zone := range s.zones {
+			s.confTxn[zone] = true
+		}
+		return nil, nil
+	}
+
+	if s.confTxn == nil {
+		return nil, errors.New("no active transaction")
+	}
+
+	switch cmd {
+	case "conf-set":
+		if req[ctlIdxSection] == "zone" && req[ctlIdxItem] == "domain" {
+			s.confTxn[normalizeZoneName(strings.ToLower(req[ctlIdxData]))] = true
+		}
+		return nil, nil
+	case "conf-unset":
+		if req[ctlIdxSection] == "zone" && req[ctlIdxID] != "" && req[ctlIdxItem] == "" {
+			zone := normalizeZoneName(strings.ToLower(req[ctlIdxID]))
+			if !s.confTxn[zone] {
+				return nil, errors.New("invalid identifier")
+			}
+			delete(s.confTxn, zone)
+		}
+		return nil, nil
+	case "conf-commit":
+		for zone := range s.confTxn {
+			if _, ok := s.zones[zone]; !ok {
+				s.zones[zone] = memoryZone{}
+			}
+		}
+		for zone := range s.zones {
+			if !s.confTxn[zone] {
+				delete(s.zones, zone)
+				delete(s.txns, zone)
+			}
+		}
+		s.confTxn = nil
+		return nil, nil
+	case "conf-abort":
+		s.confTxn = nil
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unsupported command")
+}
+
+// set adds record data to an RRset, updating the RRset TTL
+func (z memoryZone) set(owner string, req ctlData) error {
+	recordType := RecordType(strings.ToUpper(req[ctlIdxType]))
+	rdata := strings.TrimSpace(req[ctlIdxData])
+	if recordType == "" || rdata == "" {
+		return errors.New("missing record type or data")
+	}
+
+	ttl, err := strconv.ParseUint(req[ctlIdxTTL], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid TTL: %s", req[ctlIdxTTL])
+	}
+
+	if z[owner] == nil {
+		z[owner] = make(map[RecordType]*memoryRRset)
+	}
+	rrset := z[owner][recordType]
+	if rrset == nil {
+		rrset = &memoryRRset{}
+		z[owner][recordType] = rrset
+	}
+
+	rrset.ttl = uint32(ttl)
+	for _, existing := range rrset.rdata {
+		if existing == rdata {
+			return nil
+		}
+	}
+	rrset.rdata = append(rrset.rdata, rdata)
+
+	return nil
+}
+
+// unset removes a node, an RRset or a single record depending on which
+// of type and data are given
+func (z memoryZone) unset(owner string, req ctlData) error {
+	rrsets, ok := z[owner]
+	if !ok {
+		return errors.New("no such node in zone found")
+	}
+
+	recordType := RecordType(strings.ToUpper(req[ctlIdxType]))
+	if recordType == "" {
+		delete(z, owner)
+		return nil
+	}
+
+	rrset, ok := rrsets[recordType]
+	if !ok {
+		return errors.New("no such record in zone found")
+	}
+
+	if rdata := strings.TrimSpace(req[ctlIdxData]); rdata != "" {
+		kept := rrset.rdata[:0]
+		for _, existing := range rrset.rdata {
+			if existing != rdata {
+				kept = append(kept, existing)
+			}
+		}
+		if len(kept) == len(rrset.rdata) {
+			return errors.New("no such record in zone found")
+		}
+		rrset.rdata = kept
+	} else {
+		rrset.rdata = nil
+	}
+
+	if len(rrset.rdata) == 0 {
+		delete(rrsets, recordType)
+	}
+	if len(rrsets) == 0 {
+		delete(z, owner)
+	}
+
+	return nil
+}
+
+// diffMemoryZone returns the records removed from and added to the zone
+// contents by a transaction as zone-diff data units. An RRset whose TTL
+// changes is removed and added as a whole.
+func diffMemoryZone(zone string, contents, txn memoryZone) []ctlData {
+	row := func(filter, owner string, recordType RecordType, ttl uint32, rdata string) ctlData {
+		return ctlData{
+			ctlIdxZone:   zone,
+			ctlIdxOwner:  owner,
+			ctlIdxTTL:    strconv.FormatUint(uint64(ttl), 10),
+			ctlIdxType:   string(recordType),
+			ctlIdxData:   rdata,
+			ctlIdxFilter: filter,
+		}
+	}
+	missing := func(rrset *memoryRRset, rdata string, ttl uint32) bool {
+		return rrset == nil || rrset.ttl != ttl || !containsValue(rrset.rdata, rdata)
+	}
+
+	var removed, added []ctlData
+	for _, owner := range sortedKeys(contents) {
+		for _, recordType := range sortedKeys(contents[owner]) {
+			old, updated := contents[owner][recordType], txn[owner][recordType]
+			for _, rdata := range old.rdata {
+				if missing(updated, rdata, old.ttl) {
+					removed = append(removed, row(diffRemoved, owner, recordType, old.ttl, rdata))
+				}
+			}
+		}
+	}
+	for _, owner := range sortedKeys(txn) {
+		for _, recordType := range sortedKeys(txn[owner]) {
+			updated, old := txn[owner][recordType], contents[owner][recordType]
+			for _, rdata := range updated.rdata {
+				if missing(old, rdata, updated.ttl) {
+					added = append(added, row(diffAdded, owner, recordType, updated.ttl, rdata))
+				}
+			}
+		}
+	}
+
+	return append(removed, added...)
+}
+
+// readMemoryZone returns the zone contents as zone-read data units,
+// filtered by the owner and type of the request if given
+func readMemoryZone(zone string, contents memoryZone, req ctlData) []ctlData {
+	ownerFilter := ""
+	if req[ctlIdxOwner] != "" {
+		ownerFilter = qualifyOwner(req[ctlIdxOwner], zone)
+	}
+	typeFilter := RecordType(strings.ToUpper(req[ctlIdxType]))
+
+	var rows []ctlData
+	for _, owner := range sortedKeys(contents) {
+		if ownerFilter != "" && owner != ownerFilter {
+			continue
+		}
+		for _, recordType := range sortedKeys(contents[owner]) {
+			if typeFilter != "" && recordType != typeFilter {
+				continue
+			}
+			rrset := contents[owner][recordType]
+			for _, rdata := range rrset.rdata {
+				rows = append(rows, ctlData{
+					ctlIdxZone:  zone,
+					ctlIdxOwner: owner,
+					ctlIdxTTL:   strconv.FormatUint(uint64(rrset.ttl), 10),
+					ctlIdxType:  string(recordType),
+					ctlIdxData:  rdata,
+				})
+			}
+		}
+	}
+
+	return rows
+}
+
+// qualifyOwner turns a relative owner or "@" into a lowercase absolute name
+func qualifyOwner(owner, zone string) string {
+	owner = strings.ToLower(owner)
+	switch {
+	case owner == "" || owner == "@":
+		return zone
+	case strings.HasSuffix(owner, "."):
+		return owner
+	default:
+		return owner + "." + zone
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
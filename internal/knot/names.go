@@ -0,0 +1,242 @@
+package knot
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxNameLength  = 253
+	maxLabelLength = 63
+)
+
+// idnaProfile converts Unicode labels to A-labels following IDNA2008 with
+// the UTS #46 mapping, so that e.g. upper case input is accepted
+var idnaProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.Transitional(false),
+	idna.StrictDomainName(false),
+)
+
+// ToASCII converts the Unicode labels of name to punycode A-labels. ASCII
+// labels are only lowercased, so underscores and wildcards pass through.
+func ToASCII(name string) (string, error) {
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if isASCII(label) {
+			labels[i] = strings.ToLower(label)
+			continue
+		}
+		ascii, err := idnaProfile.ToASCII(label)
+		if err != nil {
+			return "", invalidFieldf("name", "invalid name: %s is not a valid IDN: %v", name, err)
+		}
+		labels[i] = ascii
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// ToUnicode converts the A-labels of name to Unicode U-labels. Labels that
+// do not decode are left as they are.
+func ToUnicode(name string) string {
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if !strings.HasPrefix(strings.ToLower(label), "xn--") {
+			continue
+		}
+		if unicode, err := idna.ToUnicode(label); err == nil {
+			labels[i] = unicode
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// unicodeOrEmpty returns the U-label form of name, or an empty string if
+// it has no A-labels
+func unicodeOrEmpty(name string) string {
+	if u := ToUnicode(name); u != name {
+		return u
+	}
+	return ""
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// ownerName returns the lowercase absolute owner name for a record name in
+// zone. The name may be "@" or empty for the apex, absolute with a trailing
+// dot, or relative to the zone. A name without trailing dot that already
+// ends in the zone is taken as absolute. Absolute names outside the zone are
+// rejected. Unicode labels are converted to A-labels.
+func ownerName(zone, name string) (string, error) {
+	apex, err := ToASCII(normalizeZoneName(zone))
+	if err != nil {
+		return "", err
+	}
+	name, err = ToASCII(strings.TrimSpace(name))
+	if err != nil {
+		return "", err
+	}
+
+	var owner string
+	switch {
+	case name == "" || name == "@":
+		return apex, nil
+	case strings.HasSuffix(name, "."):
+		owner = name
+		if !inZone(apex, owner) {
+			return "", invalidFieldf("name", "invalid name: %s is outside zone %s", name, apex)
+		}
+	case inZone(apex, name+"."):
+		owner = name + "."
+	default:
+		owner = name + "." + apex
+	}
+
+	if err := validateOwner(owner); err != nil {
+		return "", err
+	}
+	return owner, nil
+}
+
+// relativeName returns owner relative to zone, "@" for the apex. Names
+// outside the zone are returned unchanged.
+func relativeName(zone, owner string) string {
+	apex := strings.ToLower(normalizeZoneName(zone))
+	owner = strings.ToLower(normalizeZoneName(owner))
+	switch {
+	case owner == apex:
+		return "@"
+	case strings.HasSuffix(owner, "."+apex):
+		return strings.TrimSuffix(owner, "."+apex)
+	case apex == ".":
+		return strings.TrimSuffix(owner, ".")
+	default:
+		return owner
+	}
+}
+
+// inZone reports whether the absolute name owner is apex or below it
+func inZone(apex, owner string) bool {
+	return owner == apex || apex == "." || strings.HasSuffix(owner, "."+apex)
+}
+
+// validateOwner checks the labels, their characters and the length of an
+// absolute name
+func validateOwner(owner string) error {
+	if len(owner) > maxNameLength+1 {
+		return invalidFieldf("name", "invalid name: %s is longer than %d characters", owner, maxNameLength)
+	}
+	for i, label := range strings.Split(strings.TrimSuffix(owner, "."), ".") {
+		if label == "" {
+			return invalidFieldf("name", "invalid name: %s has an empty label", owner)
+		}
+		if strings.Contains(label, "*") && (label != "*" || i > 0) {
+			return invalidFieldf("name", "invalid name: %s, a wildcard must be the leftmost label and only *", owner)
+		}
+		if len(label) > maxLabelLength {
+			return invalidFieldf("name", "invalid name: label %s is longer than %d characters", label, maxLabelLength)
+		}
+		if i := strings.IndexFunc(label, isSpecialNameRune); i != -1 {
+			return invalidFieldf("name", "invalid name: %q contains %q", owner, label[i])
+		}
+	}
+	return nil
+}
+
+// isSpecialNameRune reports whether r cannot appear unescaped in a name in
+// zone file presentation format: white space, control characters, and the
+// characters that delimit strings, comments and escapes
+func isSpecialNameRune(r rune) bool {
+	return r <= ' ' || r == 0x7f || strings.ContainsRune(`"();\`, r)
+}
+
+// isWildcard reports whether name is a wildcard owner such as * or *.sub
+func isWildcard(name string) bool {
+	return name == "*" || strings.HasPrefix(name, "*.")
+}
+
+// wildcardAllowed reports whether records of recordType may have a
+// wildcard owner. Delegations, zone apex data and DNSSEC keys at a wildcard
+// would not be synthesized the way one expects (RFC 4592, section 4).
+func wildcardAllowed(recordType RecordType) bool {
+	switch recordType {
+	case RecordTypeNS, RecordTypeSOA, RecordTypeDS, RecordTypeDNSKEY, RecordTypeCDS, RecordTypeCDNSKEY:
+		return false
+	}
+	return true
+}
+
+// qualifyRecord sets the relative name and FQDN of a record read from
+// zone, along with their Unicode forms
+func qualifyRecord(zone string, record *DNSRecord) {
+	record.FQDN = strings.ToLower(normalizeZoneName(record.Name))
+	record.Name = relativeName(zone, record.FQDN)
+	record.NameUnicode = unicodeOrEmpty(record.Name)
+	record.FQDNUnicode = unicodeOrEmpty(record.FQDN)
+	record.DataUnicode = ""
+	if hasTargetName(record.Type) {
+		record.DataUnicode = targetUnicodeOrEmpty(record.Type, record.Data)
+	}
+}
+
+// qualifyRRSet sets the relative name, FQDN and their Unicode forms of an
+// RRset at the absolute name owner
+func qualifyRRSet(zone, owner string, rrset *RRSet) {
+	rrset.FQDN = strings.ToLower(normalizeZoneName(owner))
+	rrset.Name = relativeName(zone, rrset.FQDN)
+	rrset.NameUnicode = unicodeOrEmpty(rrset.Name)
+	rrset.FQDNUnicode = unicodeOrEmpty(rrset.FQDN)
+}
+
+// hasTargetName reports whether the data of recordType holds a domain name
+func hasTargetName(recordType RecordType) bool {
+	switch recordType {
+	case RecordTypeCNAME, RecordTypeNS, RecordTypePTR, RecordTypeMX, RecordTypeSRV, RecordTypeSVCB, RecordTypeHTTPS:
+		return true
+	}
+	return false
+}
+
+// targetUnicodeOrEmpty returns data with the target name of a record of
+// recordType in U-labels, or an empty string if the target has no
+// A-labels. The target is the last field of the data, but follows the
+// priority in SVCB and HTTPS records.
+func targetUnicodeOrEmpty(recordType RecordType, data string) string {
+	fields := strings.Fields(data)
+	i := len(fields) - 1
+	if recordType == RecordTypeSVCB || recordType == RecordTypeHTTPS {
+		i = 1
+	}
+	if i < 0 || i >= len(fields) {
+		return ""
+	}
+
+	target := unicodeOrEmpty(fields[i])
+	if target == "" {
+		return ""
+	}
+	fields[i] = target
+	return strings.Join(fields, " ")
+}
+
+// targetToASCII converts the domain name at the end of the data of a
+// record with a target name to A-labels
+func targetToASCII(data string) (string, error) {
+	data = strings.TrimSpace(data)
+	i := strings.LastIndexAny(data, " \t")
+	target, err := ToASCII(data[i+1:])
+	if err != nil {
+		return "", err
+	}
+	return data[:i+1] + target, nil
+}
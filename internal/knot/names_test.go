@@ -0,0 +1,95 @@
+package knot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOwnerName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "apex", in: "@", want: "example.com."},
+		{name: "empty is apex", in: "", want: "example.com."},
+		{name: "relative", in: "www", want: "www.example.com."},
+		{name: "relative with zone suffix", in: "www.example.com", want: "www.example.com."},
+		{name: "absolute", in: "WWW.Example.COM.", want: "www.example.com."},
+		{name: "wildcard", in: "*.dev", want: "*.dev.example.com."},
+		{name: "service label", in: "_sip._tcp", want: "_sip._tcp.example.com."},
+		{name: "unicode", in: "bücher", want: "xn--bcher-kva.example.com."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ownerName("example.com", tt.in)
+			if err != nil {
+				t.Fatalf("ownerName(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ownerName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnerNameInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{name: "outside zone", in: "www.example.net."},
+		{name: "empty label", in: "a..b"},
+		{name: "inner wildcard", in: "a.*.b"},
+		{name: "space", in: "foo bar"},
+		{name: "tab", in: "foo\tbar"},
+		{name: "newline", in: "foo\nbar"},
+		{name: "control character", in: "foo\x01"},
+		{name: "delete", in: "foo\x7f"},
+		{name: "quote", in: `foo"bar`},
+		{name: "semicolon", in: "foo;bar"},
+		{name: "parenthesis", in: "foo(bar)"},
+		{name: "backslash", in: `foo\bar`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ownerName("example.com", tt.in)
+			if err == nil {
+				t.Fatalf("ownerName(%q) succeeded, want an error", tt.in)
+			}
+
+			var kerr *Error
+			if !errors.As(err, &kerr) || kerr.Kind != ErrInvalid || kerr.Field != "name" {
+				t.Errorf("ownerName(%q) = %v, want an invalid name error", tt.in, err)
+			}
+		})
+	}
+}
+
+func TestQualifyRecordDataUnicode(t *testing.T) {
+	tests := []struct {
+		recordType RecordType
+		data       string
+		want       string
+	}{
+		{recordType: RecordTypeCNAME, data: "xn--bcher-kva.example.", want: "bücher.example."},
+		{recordType: RecordTypeMX, data: "10 xn--bcher-kva.example.", want: "10 bücher.example."},
+		{recordType: RecordTypeSRV, data: "10 5 5060 xn--bcher-kva.example.", want: "10 5 5060 bücher.example."},
+		{recordType: RecordTypeSVCB, data: "1 xn--bcher-kva.example. alpn=h2 port=8443", want: "1 bücher.example. alpn=h2 port=8443"},
+		{recordType: RecordTypeHTTPS, data: "0 xn--bcher-kva.example.", want: "0 bücher.example."},
+		{recordType: RecordTypeHTTPS, data: "1 . alpn=h2", want: ""},
+		{recordType: RecordTypeA, data: "192.0.2.1", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.recordType)+" "+tt.data, func(t *testing.T) {
+			record := &DNSRecord{Name: "www.example.com.", Type: tt.recordType, Data: tt.data}
+			qualifyRecord("example.com", record)
+			if record.DataUnicode != tt.want {
+				t.Errorf("data_unicode = %q, want %q", record.DataUnicode, tt.want)
+			}
+		})
+	}
+}
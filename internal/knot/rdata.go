@@ -0,0 +1,629 @@
+package knot
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TypedData holds the structured fields of record types whose data has
+// more than one part. At most one field is set, matching the record type;
+// the record's Data always carries the same value in presentation format.
+type TypedData struct {
+	TXT   TXTData    `json:"txt,omitempty" yaml:"txt,omitempty"`
+	SRV   *SRVData   `json:"srv,omitempty" yaml:"srv,omitempty"`
+	CAA   *CAAData   `json:"caa,omitempty" yaml:"caa,omitempty"`
+	TLSA  *TLSAData  `json:"tlsa,omitempty" yaml:"tlsa,omitempty"`
+	SSHFP *SSHFPData `json:"sshfp,omitempty" yaml:"sshfp,omitempty"`
+	DS    *DSData    `json:"ds,omitempty" yaml:"ds,omitempty"`
+	SVCB  *SVCBData  `json:"svcb,omitempty" yaml:"svcb,omitempty"` // SVCB and HTTPS
+}
+
+// isEmpty reports whether no typed field is set
+func (t TypedData) isEmpty() bool {
+	return t.TXT == nil && t.SRV == nil && t.CAA == nil && t.TLSA == nil && t.SSHFP == nil && t.DS == nil && t.SVCB == nil
+}
+
+// TXTData holds the character-strings of a TXT record, unescaped
+type TXTData []string
+
+// maxCharString is the maximum length of a DNS character-string in bytes
+const maxCharString = 255
+
+// SRVData holds the fields of an SRV record (RFC 2782)
+type SRVData struct {
+	Priority uint16 `json:"priority" yaml:"priority"`
+	Weight   uint16 `json:"weight" yaml:"weight"`
+	Port     uint16 `json:"port" yaml:"port"`
+	Target   string `json:"target" yaml:"target"`
+}
+
+// CAAData holds the fields of a CAA record (RFC 8659)
+type CAAData struct {
+	Flags uint8  `json:"flags" yaml:"flags"`
+	Tag   string `json:"tag" yaml:"tag"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// TLSAData holds the fields of a TLSA record (RFC 6698)
+type TLSAData struct {
+	Usage        uint8  `json:"usage" yaml:"usage"`
+	Selector     uint8  `json:"selector" yaml:"selector"`
+	MatchingType uint8  `json:"matching_type" yaml:"matching_type"`
+	Certificate  string `json:"certificate" yaml:"certificate"` // hex
+}
+
+// SSHFPData holds the fields of an SSHFP record (RFC 4255)
+type SSHFPData struct {
+	Algorithm       uint8  `json:"algorithm" yaml:"algorithm"`
+	FingerprintType uint8  `json:"fingerprint_type" yaml:"fingerprint_type"`
+	Fingerprint     string `json:"fingerprint" yaml:"fingerprint"` // hex
+}
+
+// DSData holds the fields of a DS record (RFC 4034)
+type DSData struct {
+	KeyTag     uint16 `json:"key_tag" yaml:"key_tag"`
+	Algorithm  uint8  `json:"algorithm" yaml:"algorithm"`
+	DigestType uint8  `json:"digest_type" yaml:"digest_type"`
+	Digest     string `json:"digest" yaml:"digest"` // hex
+}
+
+// SVCBData holds the fields of an SVCB or HTTPS record (RFC 9460).
+// Params maps parameter names such as "alpn" or "port" to their values;
+// parameters without a value, like "no-default-alpn", map to "".
+type SVCBData struct {
+	Priority uint16            `json:"priority" yaml:"priority"`
+	Target   string            `json:"target" yaml:"target"`
+	Params   map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// rdataFields is implemented by the typed data of structured record types
+type rdataFields interface {
+	validate() error
+	String() string
+}
+
+// isStructuredType reports whether records of type t have typed fields
+func isStructuredType(t RecordType) bool {
+	switch t {
+	case RecordTypeTXT, RecordTypeSRV, RecordTypeCAA, RecordTypeTLSA, RecordTypeSSHFP, RecordTypeDS, RecordTypeSVCB, RecordTypeHTTPS:
+		return true
+	}
+	return false
+}
+
+// fields returns the typed data of a structured record, parsing Data into
+// the matching field when it is not set
+func (r *DNSRecord) fields() (rdataFields, error) {
+	var err error
+	switch r.Type {
+	case RecordTypeTXT:
+		if r.TXT == nil {
+			r.TXT, err = parseTXT(r.Data)
+		}
+		return &r.TXT, err
+	case RecordTypeSRV:
+		if r.SRV == nil {
+			r.SRV, err = parseSRV(r.Data)
+		}
+		return r.SRV, err
+	case RecordTypeCAA:
+		if r.CAA == nil {
+			r.CAA, err = parseCAA(r.Data)
+		}
+		return r.CAA, err
+	case RecordTypeTLSA:
+		if r.TLSA == nil {
+			r.TLSA, err = parseTLSA(r.Data)
+		}
+		return r.TLSA, err
+	case RecordTypeSSHFP:
+		if r.SSHFP == nil {
+			r.SSHFP, err = parseSSHFP(r.Data)
+		}
+		return r.SSHFP, err
+	case RecordTypeDS:
+		if r.DS == nil {
+			r.DS, err = parseDS(r.Data)
+		}
+		return r.DS, err
+	case RecordTypeSVCB, RecordTypeHTTPS:
+		if r.SVCB == nil {
+			r.SVCB, err = parseSVCB(r.Data)
+		}
+		return r.SVCB, err
+	}
+	return nil, fmt.Errorf("%s records have no typed fields", r.Type)
+}
+
+// parseUints parses the leading numeric fields of record data and returns
+// the remaining fields
+func parseUints(rdata string, recordType RecordType, bits ...int) ([]uint64, []string, error) {
+	fields := splitQuoted(rdata)
+	if len(fields) <= len(bits) {
+		return nil, nil, fmt.Errorf("invalid %s record data: %s", recordType, rdata)
+	}
+
+	numbers := make([]uint64, len(bits))
+	for i, size := range bits {
+		n, err := strconv.ParseUint(fields[i], 10, size)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s record data: %s", recordType, rdata)
+		}
+		numbers[i] = n
+	}
+	return numbers, fields[len(bits):], nil
+}
+
+// parseTXT parses TXT record data. Data starting with a quote is read as
+// a sequence of character-strings in presentation format; anything else is
+// taken literally as a single string.
+func parseTXT(rdata string) (TXTData, error) {
+	rdata = strings.TrimSpace(rdata)
+	if !strings.HasPrefix(rdata, `"`) {
+		return TXTData{rdata}, nil
+	}
+
+	var strs TXTData
+	for _, field := range splitQuoted(rdata) {
+		if len(field) < 2 || !strings.HasPrefix(field, `"`) || !strings.HasSuffix(field, `"`) {
+			return nil, fmt.Errorf("invalid TXT record data: %s", rdata)
+		}
+		str, err := unquoteString(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TXT record data: %w", err)
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+// validate splits strings longer than a character-string allows, keeping
+// UTF-8 sequences intact
+func (d *TXTData) validate() error {
+	if len(*d) == 0 {
+		return fmt.Errorf("TXT record needs at least one string")
+	}
+
+	var chunks TXTData
+	for _, str := range *d {
+		for len(str) > maxCharString {
+			// A UTF-8 sequence is at most utf8.UTFMax bytes long; data that
+			// is not UTF-8 is cut at the limit
+			cut := maxCharString
+			for cut > maxCharString-utf8.UTFMax && !utf8.RuneStart(str[cut]) {
+				cut--
+			}
+			if !utf8.RuneStart(str[cut]) {
+				cut = maxCharString
+			}
+			chunks = append(chunks, str[:cut])
+			str = str[cut:]
+		}
+		chunks = append(chunks, str)
+	}
+	*d = chunks
+	return nil
+}
+
+// String returns the strings quoted and escaped as KnotDNS prints them
+func (d *TXTData) String() string {
+	quoted := make([]string, len(*d))
+	for i, str := range *d {
+		quoted[i] = quoteString(str)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func parseSRV(rdata string) (*SRVData, error) {
+	n, rest, err := parseUints(rdata, RecordTypeSRV, 16, 16, 16)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 1 {
+		return nil, fmt.Errorf("invalid SRV record data: %s", rdata)
+	}
+	return &SRVData{Priority: uint16(n[0]), Weight: uint16(n[1]), Port: uint16(n[2]), Target: rest[0]}, nil
+}
+
+func (d *SRVData) validate() error {
+	if d.Target == "" {
+		return fmt.Errorf("SRV target cannot be empty")
+	}
+	target, err := ToASCII(normalizeZoneName(d.Target))
+	if err != nil {
+		return err
+	}
+	d.Target = target
+	return nil
+}
+
+func (d *SRVData) String() string {
+	return fmt.Sprintf("%d %d %d %s", d.Priority, d.Weight, d.Port, d.Target)
+}
+
+func parseCAA(rdata string) (*CAAData, error) {
+	n, rest, err := parseUints(rdata, RecordTypeCAA, 8)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 2 {
+		return nil, fmt.Errorf("invalid CAA record data: %s", rdata)
+	}
+	value, err := unquoteString(rest[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid CAA value: %w", err)
+	}
+	return &CAAData{Flags: uint8(n[0]), Tag: rest[0], Value: value}, nil
+}
+
+// caaTagRe matches a CAA property tag
+var caaTagRe = regexp.MustCompile(`^[a-zA-Z0-9]{1,15}$`)
+
+func (d *CAAData) validate() error {
+	if !caaTagRe.MatchString(d.Tag) {
+		return fmt.Errorf("invalid CAA tag: %s", d.Tag)
+	}
+	d.Tag = strings.ToLower(d.Tag)
+	return nil
+}
+
+func (d *CAAData) String() string {
+	return fmt.Sprintf("%d %s %s", d.Flags, d.Tag, quoteString(d.Value))
+}
+
+func parseTLSA(rdata string) (*TLSAData, error) {
+	n, rest, err := parseUints(rdata, RecordTypeTLSA, 8, 8, 8)
+	if err != nil {
+		return nil, err
+	}
+	return &TLSAData{
+		Usage:        uint8(n[0]),
+		Selector:     uint8(n[1]),
+		MatchingType: uint8(n[2]),
+		Certificate:  strings.Join(rest, ""),
+	}, nil
+}
+
+func (d *TLSAData) validate() error {
+	if d.Usage > 3 {
+		return fmt.Errorf("invalid TLSA usage: %d", d.Usage)
+	}
+	if d.Selector > 1 {
+		return fmt.Errorf("invalid TLSA selector: %d", d.Selector)
+	}
+
+	lengths := map[uint8]int{0: 0, 1: 32, 2: 64}
+	length, ok := lengths[d.MatchingType]
+	if !ok {
+		return fmt.Errorf("invalid TLSA matching type: %d", d.MatchingType)
+	}
+
+	certificate, err := normalizeHex(d.Certificate, length)
+	if err != nil {
+		return fmt.Errorf("invalid TLSA certificate data: %w", err)
+	}
+	d.Certificate = certificate
+	return nil
+}
+
+func (d *TLSAData) String() string {
+	return fmt.Sprintf("%d %d %d %s", d.Usage, d.Selector, d.MatchingType, d.Certificate)
+}
+
+func parseSSHFP(rdata string) (*SSHFPData, error) {
+	n, rest, err := parseUints(rdata, RecordTypeSSHFP, 8, 8)
+	if err != nil {
+		return nil, err
+	}
+	return &SSHFPData{Algorithm: uint8(n[0]), FingerprintType: uint8(n[1]), Fingerprint: strings.Join(rest, "")}, nil
+}
+
+func (d *SSHFPData) validate() error {
+	switch d.Algorithm {
+	case 1, 2, 3, 4, 6: // RSA, DSA, ECDSA, Ed25519, Ed448
+	default:
+		return fmt.Errorf("invalid SSHFP algorithm: %d", d.Algorithm)
+	}
+
+	lengths := map[uint8]int{1: 20, 2: 32}
+	length, ok := lengths[d.FingerprintType]
+	if !ok {
+		return fmt.Errorf("invalid SSHFP fingerprint type: %d", d.FingerprintType)
+	}
+
+	fingerprint, err := normalizeHex(d.Fingerprint, length)
+	if err != nil {
+		return fmt.Errorf("invalid SSHFP fingerprint: %w", err)
+	}
+	d.Fingerprint = fingerprint
+	return nil
+}
+
+func (d *SSHFPData) String() string {
+	return fmt.Sprintf("%d %d %s", d.Algorithm, d.FingerprintType, d.Fingerprint)
+}
+
+func parseDS(rdata string) (*DSData, error) {
+	n, rest, err := parseUints(rdata, RecordTypeDS, 16, 8, 8)
+	if err != nil {
+		return nil, err
+	}
+	return &DSData{KeyTag: uint16(n[0]), Algorithm: uint8(n[1]), DigestType: uint8(n[2]), Digest: strings.Join(rest, "")}, nil
+}
+
+func (d *DSData) validate() error {
+	if d.Algorithm == 0 {
+		return fmt.Errorf("invalid DS algorithm: %d", d.Algorithm)
+	}
+
+	lengths := map[uint8]int{1: 20, DigestSHA256: 32, 3: 32, DigestSHA384: 48}
+	length, ok := lengths[d.DigestType]
+	if !ok {
+		return fmt.Errorf("invalid DS digest type: %d", d.DigestType)
+	}
+
+	digest, err := normalizeHex(d.Digest, length)
+	if err != nil {
+		return fmt.Errorf("invalid DS digest: %w", err)
+	}
+	d.Digest = digest
+	return nil
+}
+
+func (d *DSData) String() string {
+	return fmt.Sprintf("%d %d %d %s", d.KeyTag, d.Algorithm, d.DigestType, d.Digest)
+}
+
+// normalizeHex checks that s is hex encoded data of the given length in
+// bytes, any length if zero, and returns it in upper case
+func normalizeHex(s string, length int) (string, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return "", fmt.Errorf("not a hex string: %s", s)
+	}
+	if length > 0 && len(data) != length {
+		return "", fmt.Errorf("expected %d bytes, got %d", length, len(data))
+	}
+	return strings.ToUpper(s), nil
+}
+
+// svcParamKeys maps SVCB parameter names to their key numbers
+var svcParamKeys = map[string]int{
+	"mandatory":       0,
+	"alpn":            1,
+	"no-default-alpn": 2,
+	"port":            3,
+	"ipv4hint":        4,
+	"ech":             5,
+	"ipv6hint":        6,
+	"dohpath":         7,
+	"ohttp":           8,
+}
+
+// svcParamKey returns the key number of an SVCB parameter name, including
+// the generic keyNNNNN form
+func svcParamKey(name string) (int, bool) {
+	if key, ok := svcParamKeys[name]; ok {
+		return key, true
+	}
+	if strings.HasPrefix(name, "key") {
+		if key, err := strconv.ParseUint(name[3:], 10, 16); err == nil {
+			return int(key), true
+		}
+	}
+	return 0, false
+}
+
+func parseSVCB(rdata string) (*SVCBData, error) {
+	n, rest, err := parseUints(rdata, RecordTypeSVCB, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SVCBData{Priority: uint16(n[0]), Target: rest[0]}
+	for _, param := range rest[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		value, err := unquoteString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SVCB parameter %s: %w", name, err)
+		}
+		if data.Params == nil {
+			data.Params = make(map[string]string)
+		}
+		data.Params[strings.ToLower(name)] = value
+	}
+	return data, nil
+}
+
+func (d *SVCBData) validate() error {
+	if d.Target == "" {
+		return fmt.Errorf("SVCB target cannot be empty")
+	}
+	target, err := ToASCII(normalizeZoneName(d.Target))
+	if err != nil {
+		return err
+	}
+	d.Target = target
+
+	if d.Priority == 0 && len(d.Params) > 0 {
+		return fmt.Errorf("SVCB alias mode (priority 0) does not take parameters")
+	}
+
+	for name, value := range d.Params {
+		if _, ok := svcParamKey(name); !ok {
+			return fmt.Errorf("invalid SVCB parameter: %s", name)
+		}
+
+		switch name {
+		case "no-default-alpn", "ohttp":
+			if value != "" {
+				return fmt.Errorf("SVCB parameter %s does not take a value", name)
+			}
+		case "port":
+			if _, err := strconv.ParseUint(value, 10, 16); err != nil {
+				return fmt.Errorf("invalid SVCB port: %s", value)
+			}
+		case "ipv4hint", "ipv6hint":
+			for _, addr := range strings.Split(value, ",") {
+				ip := net.ParseIP(addr)
+				if ip == nil || (name == "ipv4hint") != (ip.To4() != nil) {
+					return fmt.Errorf("invalid SVCB %s address: %s", name, addr)
+				}
+			}
+		case "ech":
+			if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+				return fmt.Errorf("invalid SVCB ech: %w", err)
+			}
+		case "mandatory":
+			for _, key := range strings.Split(value, ",") {
+				if _, ok := svcParamKey(key); !ok || key == "mandatory" {
+					return fmt.Errorf("invalid SVCB mandatory key: %s", key)
+				}
+				if _, ok := d.Params[key]; !ok {
+					return fmt.Errorf("SVCB mandatory key %s is missing", key)
+				}
+			}
+		case "alpn":
+			if value == "" {
+				return fmt.Errorf("SVCB alpn cannot be empty")
+			}
+		}
+	}
+
+	if _, ok := d.Params["no-default-alpn"]; ok {
+		if _, ok := d.Params["alpn"]; !ok {
+			return fmt.Errorf("SVCB no-default-alpn requires alpn")
+		}
+	}
+
+	return nil
+}
+
+// String returns the record data with parameters in key order
+func (d *SVCBData) String() string {
+	names := make([]string, 0, len(d.Params))
+	for name := range d.Params {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, _ := svcParamKey(names[i])
+		b, _ := svcParamKey(names[j])
+		return a < b
+	})
+
+	parts := []string{strconv.FormatUint(uint64(d.Priority), 10), d.Target}
+	for _, name := range names {
+		value := d.Params[name]
+		if value == "" {
+			parts = append(parts, name)
+			continue
+		}
+		if strings.ContainsAny(value, " \t\"\\;()") {
+			value = quoteString(value)
+		}
+		parts = append(parts, name+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitQuoted splits record data into whitespace separated fields, keeping
+// quoted strings, which may contain whitespace, within a single field
+func splitQuoted(s string) []string {
+	var fields []string
+	var field strings.Builder
+	inQuotes, escaped, started := false, false, false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && (r == ' ' || r == '\t'):
+			if started {
+				fields = append(fields, field.String())
+				field.Reset()
+				started = false
+			}
+			continue
+		}
+		field.WriteRune(r)
+		started = true
+	}
+	if started {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
+
+// quoteString returns s as a quoted character string, escaping quotes,
+// backslashes and non-printable bytes (RFC 1035, section 5.1)
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// unquoteString decodes a character string in presentation format, quoted
+// or not, resolving \X and \DDD escapes
+func unquoteString(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		if i+1 >= len(s) {
+			return "", fmt.Errorf("dangling escape in %q", s)
+		}
+		if isDigit(s[i+1]) {
+			if i+3 >= len(s) || !isDigit(s[i+2]) || !isDigit(s[i+3]) {
+				return "", fmt.Errorf("invalid escape in %q", s)
+			}
+			n, _ := strconv.Atoi(s[i+1 : i+4])
+			if n > 255 {
+				return "", fmt.Errorf("invalid escape in %q", s)
+			}
+			b.WriteByte(byte(n))
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i+1])
+		i++
+	}
+
+	return b.String(), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
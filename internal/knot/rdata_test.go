@@ -0,0 +1,124 @@
+package knot
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTXTDataValidateChunks(t *testing.T) {
+	tests := []struct {
+		name   string
+		rdata  string
+		chunks []int // expected chunk lengths in bytes
+	}{
+		{
+			name:   "short string",
+			rdata:  `"hello"`,
+			chunks: []int{5},
+		},
+		{
+			name:   "ascii at the limit",
+			rdata:  `"` + strings.Repeat("a", 255) + `"`,
+			chunks: []int{255},
+		},
+		{
+			name:   "ascii over the limit",
+			rdata:  `"` + strings.Repeat("a", 300) + `"`,
+			chunks: []int{255, 45},
+		},
+		{
+			name:   "escaped high bytes",
+			rdata:  `"` + strings.Repeat(`\128`, 300) + `"`,
+			chunks: []int{255, 45},
+		},
+		{
+			name:   "two byte rune across the limit",
+			rdata:  `"` + strings.Repeat("a", 254) + "é" + `"`,
+			chunks: []int{254, 2},
+		},
+		{
+			name:   "four byte rune across the limit",
+			rdata:  `"` + strings.Repeat("a", 253) + "😀" + `"`,
+			chunks: []int{253, 4},
+		},
+		{
+			name:   "rune ending at the limit",
+			rdata:  `"` + strings.Repeat("a", 253) + "é" + "b" + `"`,
+			chunks: []int{255, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseTXT(tt.rdata)
+			if err != nil {
+				t.Fatalf("parseTXT: %v", err)
+			}
+			want := strings.Join(data, "")
+
+			if err := data.validate(); err != nil {
+				t.Fatalf("validate: %v", err)
+			}
+
+			if len(data) != len(tt.chunks) {
+				t.Fatalf("got %d chunks, want %d", len(data), len(tt.chunks))
+			}
+			for i, chunk := range data {
+				if len(chunk) != tt.chunks[i] {
+					t.Errorf("chunk %d is %d bytes, want %d", i, len(chunk), tt.chunks[i])
+				}
+			}
+			if got := strings.Join(data, ""); got != want {
+				t.Errorf("chunks do not add up to the original string")
+			}
+			if utf8.ValidString(want) {
+				for i, chunk := range data {
+					if !utf8.ValidString(chunk) {
+						t.Errorf("chunk %d splits a UTF-8 sequence", i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTXTRecordValidateHighBytes(t *testing.T) {
+	record := &DNSRecord{Name: "txt", Type: RecordTypeTXT, TTL: 300, Data: `"` + strings.Repeat(`\200`, 600) + `"`}
+	if err := record.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	data, err := parseTXT(record.RData())
+	if err != nil {
+		t.Fatalf("parseTXT: %v", err)
+	}
+	if len(data) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(data))
+	}
+}
+
+func TestTargetsToASCII(t *testing.T) {
+	tests := []struct {
+		recordType RecordType
+		data       string
+		want       string
+	}{
+		{RecordTypeCNAME, "bücher.example.", "xn--bcher-kva.example."},
+		{RecordTypeSRV, "10 5 443 bücher.example.", "10 5 443 xn--bcher-kva.example."},
+		{RecordTypeSVCB, "1 bücher.example. alpn=h2", "1 xn--bcher-kva.example. alpn=h2"},
+		{RecordTypeHTTPS, "0 Bücher.Example.", "0 xn--bcher-kva.example."},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.recordType), func(t *testing.T) {
+			record := &DNSRecord{Name: "www", Type: tt.recordType, TTL: 300, Data: tt.data}
+			if err := record.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if got := record.RData(); got != tt.want {
+				t.Errorf("RData() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
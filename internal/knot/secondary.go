@@ -0,0 +1,20 @@
+package knot
+
+import "context"
+
+// RefreshZone makes knotd check the primary of a secondary zone for a newer
+// serial and transfer the zone if there is one
+func (c *Client) RefreshZone(ctx context.Context, zone string) error {
+	return c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-refresh"})
+}
+
+// RetransferZone makes knotd transfer a secondary zone in full from its
+// primary, regardless of the serial
+func (c *Client) RetransferZone(ctx context.Context, zone string) error {
+	return c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-retransfer"})
+}
+
+// NotifyZone sends NOTIFY messages for a zone to its configured remotes
+func (c *Client) NotifyZone(ctx context.Context, zone string) error {
+	return c.zoneCommand(ctx, zone, ctlData{ctlIdxCmd: "zone-notify"})
+}
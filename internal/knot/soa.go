@@ -0,0 +1,261 @@
+package knot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Serial policies applied when the SOA is edited through the API. The names
+// match the serial-policy values of knot.conf.
+const (
+	SerialPolicyIncrement  = "increment"  // serial + 1
+	SerialPolicyUnixtime   = "unixtime"   // seconds since the epoch
+	SerialPolicyDateserial = "dateserial" // YYYYMMDDnn
+)
+
+// SOA represents the typed fields of a zone's SOA record
+type SOA struct {
+	MName        string `json:"mname"`
+	RName        string `json:"rname"`
+	Serial       uint32 `json:"serial"`
+	Refresh      uint32 `json:"refresh"`
+	Retry        uint32 `json:"retry"`
+	Expire       uint32 `json:"expire"`
+	Minimum      uint32 `json:"minimum"`
+	TTL          uint32 `json:"ttl"`
+	SerialPolicy string `json:"serial_policy"`
+}
+
+// UpdateSOARequest represents a request to update SOA fields. Without an
+// explicit serial the next one is derived from the zone's serial policy.
+type UpdateSOARequest struct {
+	MName   *string `json:"mname,omitempty"`
+	RName   *string `json:"rname,omitempty"`
+	Serial  *uint32 `json:"serial,omitempty"`
+	Refresh *uint32 `json:"refresh,omitempty"`
+	Retry   *uint32 `json:"retry,omitempty"`
+	Expire  *uint32 `json:"expire,omitempty"`
+	Minimum *uint32 `json:"minimum,omitempty"`
+	TTL     *uint32 `json:"ttl,omitempty"`
+}
+
+// IsValidSerialPolicy checks if a serial policy is supported
+func IsValidSerialPolicy(policy string) bool {
+	switch policy {
+	case SerialPolicyIncrement, SerialPolicyUnixtime, SerialPolicyDateserial:
+		return true
+	}
+	return false
+}
+
+// parseSOA parses SOA record data in presentation format
+func parseSOA(rdata string, ttl uint32) (*SOA, error) {
+	fields := strings.Fields(rdata)
+	if len(fields) != 7 {
+		return nil, fmt.Errorf("invalid SOA record data: %s", rdata)
+	}
+
+	var numbers [5]uint32
+	for i, field := range fields[2:] {
+		n, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOA field %q: %w", field, err)
+		}
+		numbers[i] = uint32(n)
+	}
+
+	return &SOA{
+		MName:   fields[0],
+		RName:   fields[1],
+		Serial:  numbers[0],
+		Refresh: numbers[1],
+		Retry:   numbers[2],
+		Expire:  numbers[3],
+		Minimum: numbers[4],
+		TTL:     ttl,
+	}, nil
+}
+
+// RData returns the SOA record data in presentation format
+func (s *SOA) RData() string {
+	return fmt.Sprintf("%s %s %d %d %d %d %d", s.MName, s.RName, s.Serial, s.Refresh, s.Retry, s.Expire, s.Minimum)
+}
+
+// Validate validates the SOA fields
+func (s *SOA) Validate() error {
+	if s.MName == "" {
+		return invalidFieldf("mname", "invalid SOA: mname is required")
+	}
+	if s.RName == "" {
+		return invalidFieldf("rname", "invalid SOA: rname is required")
+	}
+	if s.Refresh == 0 || s.Retry == 0 || s.Expire == 0 {
+		return invalidFieldf("refresh", "invalid SOA: refresh, retry and expire must be positive")
+	}
+	if s.Retry > s.Refresh {
+		return invalidFieldf("retry", "invalid SOA: retry must not exceed refresh")
+	}
+	if s.Expire <= s.Refresh {
+		return invalidFieldf("expire", "invalid SOA: expire must be greater than refresh")
+	}
+
+	s.MName = normalizeZoneName(s.MName)
+	s.RName = normalizeZoneName(s.RName)
+	return nil
+}
+
+// serialGreater reports whether a is greater than b in RFC 1982 serial
+// number arithmetic
+func serialGreater(a, b uint32) bool {
+	return a != b && a-b < 1<<31
+}
+
+// nextSerial returns the serial following current under policy
+func nextSerial(policy string, current uint32, now time.Time) uint32 {
+	var candidate uint32
+	switch policy {
+	case SerialPolicyUnixtime:
+		candidate = uint32(now.Unix())
+	case SerialPolicyDateserial:
+		date, _ := strconv.ParseUint(now.UTC().Format("20060102"), 10, 32)
+		candidate = uint32(date) * 100
+	}
+
+	if serialGreater(candidate, current) {
+		return candidate
+	}
+	if policy == SerialPolicyDateserial && current%100 == 99 {
+		// The changes of the day of current are used up; move on to the
+		// next day rather than to nn 00 of an invalid date such as the 32nd
+		if day, err := time.Parse("20060102", strconv.FormatUint(uint64(current/100), 10)); err == nil {
+			next, _ := strconv.ParseUint(day.AddDate(0, 0, 1).Format("20060102"), 10, 32)
+			return uint32(next) * 100
+		}
+	}
+	return current + 1
+}
+
+// validateSerial checks that an explicitly requested serial moves the zone
+// forward and fits the serial policy
+func validateSerial(policy string, serial, current uint32) error {
+	if !serialGreater(serial, current) {
+		return invalidFieldf("serial", "invalid serial %d: must be greater than current serial %d", serial, current)
+	}
+
+	if policy == SerialPolicyDateserial {
+		if _, err := time.Parse("20060102", strconv.FormatUint(uint64(serial/100), 10)); err != nil {
+			return invalidFieldf("serial", "invalid serial %d: dateserial policy requires YYYYMMDDnn", serial)
+		}
+	}
+
+	return nil
+}
+
+// serialPolicy returns the serial policy configured for zone
+func (c *Client) serialPolicy(zone string) string {
+	normalizedZone := strings.ToLower(normalizeZoneName(zone))
+	for name, policy := range c.serialPolicies {
+		if strings.ToLower(normalizeZoneName(name)) == normalizedZone {
+			return policy
+		}
+	}
+	if c.defaultSerialPolicy != "" {
+		return c.defaultSerialPolicy
+	}
+	return SerialPolicyIncrement
+}
+
+// GetSOA returns the SOA of a zone
+func (c *Client) GetSOA(ctx context.Context, zone string) (*SOA, error) {
+	records, err := c.FindRecords(ctx, zone, "@", RecordTypeSOA)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: SOA in zone %s", ErrRecordNotFound, zone)
+	}
+
+	soa, err := parseSOA(records[0].Data, records[0].TTL)
+	if err != nil {
+		return nil, err
+	}
+	soa.SerialPolicy = c.serialPolicy(zone)
+
+	return soa, nil
+}
+
+// UpdateSOA updates SOA fields of a zone and moves its serial forward,
+// either to the requested value or according to the zone's serial policy
+func (c *Client) UpdateSOA(ctx context.Context, zone string, updates *UpdateSOARequest) (*SOA, error) {
+	if !c.IsZoneAllowed(zone) {
+		return nil, fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	soa, err := c.GetSOA(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	current := soa.Serial
+
+	// Apply updates
+	if updates.MName != nil {
+		soa.MName = *updates.MName
+	}
+	if updates.RName != nil {
+		soa.RName = *updates.RName
+	}
+	if updates.Refresh != nil {
+		soa.Refresh = *updates.Refresh
+	}
+	if updates.Retry != nil {
+		soa.Retry = *updates.Retry
+	}
+	if updates.Expire != nil {
+		soa.Expire = *updates.Expire
+	}
+	if updates.Minimum != nil {
+		soa.Minimum = *updates.Minimum
+	}
+	if updates.TTL != nil {
+		soa.TTL = *updates.TTL
+	}
+
+	if updates.Serial != nil {
+		if err := validateSerial(soa.SerialPolicy, *updates.Serial, current); err != nil {
+			return nil, err
+		}
+		soa.Serial = *updates.Serial
+	} else {
+		soa.Serial = nextSerial(soa.SerialPolicy, current, time.Now())
+	}
+
+	if err := soa.Validate(); err != nil {
+		return nil, err
+	}
+
+	apex := normalizeZoneName(zone)
+	err = c.transaction(ctx, zone, func(t *txn) error {
+		if err := t.unset(apex, RecordTypeSOA, ""); err != nil {
+			return fmt.Errorf("failed to remove old SOA from zone %s: %w", zone, err)
+		}
+		if err := t.set(apex, soa.TTL, RecordTypeSOA, soa.RData()); err != nil {
+			return fmt.Errorf("failed to add SOA to zone %s: %w", zone, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.logWrite(ctx, "Updated SOA of zone %s, serial %d -> %d (%s)", zone, current, soa.Serial, soa.SerialPolicy)
+	return soa, nil
+}
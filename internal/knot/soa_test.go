@@ -0,0 +1,43 @@
+package knot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextSerial(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		policy  string
+		current uint32
+		want    uint32
+	}{
+		{name: "increment", policy: SerialPolicyIncrement, current: 41, want: 42},
+		{name: "increment wraps", policy: SerialPolicyIncrement, current: 1<<32 - 1, want: 0},
+		{name: "unixtime", policy: SerialPolicyUnixtime, current: 41, want: uint32(now.Unix())},
+		{name: "unixtime ahead", policy: SerialPolicyUnixtime, current: uint32(now.Unix()) + 5, want: uint32(now.Unix()) + 6},
+		{name: "dateserial of an earlier day", policy: SerialPolicyDateserial, current: 2026101507, want: 2026101600},
+		{name: "dateserial of today", policy: SerialPolicyDateserial, current: 2026101607, want: 2026101608},
+		{name: "dateserial of today at 99", policy: SerialPolicyDateserial, current: 2026101699, want: 2026101700},
+		{name: "dateserial at 99 on the last day of a month", policy: SerialPolicyDateserial, current: 2026103199, want: 2026110100},
+		{name: "dateserial at 99 on the last day of the year", policy: SerialPolicyDateserial, current: 2026123199, want: 2027010100},
+		{name: "dateserial at 99 on February 28", policy: SerialPolicyDateserial, current: 2027022899, want: 2027030100},
+		{name: "dateserial from a serial that is not a date", policy: SerialPolicyDateserial, current: 4000000099, want: 4000000100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextSerial(tt.policy, tt.current, now)
+			if got != tt.want {
+				t.Errorf("nextSerial(%s, %d) = %d, want %d", tt.policy, tt.current, got, tt.want)
+			}
+			if tt.policy == SerialPolicyDateserial && tt.current < 4000000000 {
+				if err := validateSerial(tt.policy, got, tt.current); err != nil {
+					t.Errorf("nextSerial(%s, %d) = %d: %v", tt.policy, tt.current, got, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package knot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Stat is a single knotd counter as reported by the stats and zone-stats
+// commands, e.g. mod-stats.query-type[AAAA] = 12 for zone example.com.
+type Stat struct {
+	Section string  // module, e.g. "server" or "mod-stats"
+	Item    string  // counter, e.g. "query-type"
+	Index   string  // counter index, e.g. "AAAA", optional
+	Zone    string  // empty for server-wide counters
+	Value   float64 // counter value
+}
+
+// statLineRe matches knotc stats output: [zone] section.item[index] = value
+var statLineRe = regexp.MustCompile(`^(?:\[([^\]]+)\]\s+)?([a-z0-9-]+)\.([a-z0-9-]+)(?:\[([^\]]*)\])?\s*=\s*(\S+)$`)
+
+// GetStats returns knotd's server-wide counters and the counters of every
+// allowed zone
+func (c *Client) GetStats(ctx context.Context) ([]Stat, error) {
+	var stats []Stat
+	collect := func(data ctlData) error {
+		if data[ctlIdxZone] != "" && !c.IsZoneAllowed(data[ctlIdxZone]) {
+			return nil
+		}
+		value, err := strconv.ParseFloat(data[ctlIdxData], 64)
+		if err != nil {
+			return nil
+		}
+		stats = append(stats, Stat{
+			Section: data[ctlIdxSection],
+			Item:    data[ctlIdxItem],
+			Index:   data[ctlIdxID],
+			Zone:    data[ctlIdxZone],
+			Value:   value,
+		})
+		return nil
+	}
+
+	if err := c.command(ctx, ctlData{ctlIdxCmd: "stats"}, collect); err != nil {
+		return nil, fmt.Errorf("failed to get server statistics: %w", err)
+	}
+	// Zone counters only exist for zones using mod-stats
+	if err := c.command(ctx, ctlData{ctlIdxCmd: "zone-stats"}, collect); err != nil {
+		c.logger.Warnf("Failed to get zone statistics: %v", err)
+	}
+
+	return stats, nil
+}
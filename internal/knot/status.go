@@ -0,0 +1,142 @@
+package knot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ZoneStatus represents the state of a zone as reported by zone-status
+type ZoneStatus struct {
+	Zone        string            `json:"zone"`
+	Role        string            `json:"role"`
+	Serial      *uint32           `json:"serial"` // nil while the zone is not loaded
+	Transaction bool              `json:"transaction"`
+	Frozen      bool              `json:"frozen"`
+	Catalog     string            `json:"catalog,omitempty"`
+	Maintenance bool              `json:"maintenance"` // frozen or set by hyprknot; changes are rejected
+	Events      map[string]string `json:"events"`      // event name to scheduled time, e.g. "+1h2m"
+}
+
+// applyStatusItem records one "type: data" item of zone-status output.
+// Items that are not zone properties are scheduled events.
+func (s *ZoneStatus) applyStatusItem(name, value string) {
+	switch name {
+	case "role":
+		s.Role = value
+	case "serial":
+		if serial, err := strconv.ParseUint(value, 10, 32); err == nil {
+			n := uint32(serial)
+			s.Serial = &n
+		}
+	case "transaction":
+		s.Transaction = value == "open"
+	case "freeze":
+		s.Frozen = value != "" && value != "no"
+	case "catalog":
+		s.Catalog = value
+	default:
+		s.Events[name] = value
+	}
+}
+
+// zoneStatus runs zone-status for one zone, or for all zones if zone is
+// empty, and returns the statuses in zone order
+func (c *Client) zoneStatus(ctx context.Context, zone string) ([]ZoneStatus, error) {
+	statuses := make(map[string]*ZoneStatus)
+
+	err := c.command(ctx, ctlData{ctlIdxCmd: "zone-status", ctlIdxZone: zone}, func(data ctlData) error {
+		name := data[ctlIdxZone]
+		if name == "" || data[ctlIdxType] == "" {
+			return nil
+		}
+
+		status, ok := statuses[name]
+		if !ok {
+			status = &ZoneStatus{Zone: name, Events: make(map[string]string)}
+			statuses[name] = status
+		}
+		status.applyStatusItem(data[ctlIdxType], data[ctlIdxData])
+		status.Maintenance = status.Frozen || c.maintenanceSet(name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ZoneStatus, 0, len(statuses))
+	for _, status := range statuses {
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Zone < result[j].Zone })
+
+	return result, nil
+}
+
+// IsSecondary reports whether the zone status describes a zone received by
+// zone transfer
+func (s *ZoneStatus) IsSecondary() bool {
+	return s.Role == "slave" || s.Role == "secondary"
+}
+
+// requirePrimary fails with ErrSecondaryZone if zone is a secondary
+func (c *Client) requirePrimary(ctx context.Context, zone string) error {
+	statuses, err := c.zoneStatus(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("failed to get status of zone %s: %w", zone, err)
+	}
+	if len(statuses) > 0 && statuses[0].IsSecondary() {
+		return fmt.Errorf("%w: %s", ErrSecondaryZone, zone)
+	}
+	return nil
+}
+
+// GetZoneStatus returns the status of a zone
+func (c *Client) GetZoneStatus(ctx context.Context, zone string) (*ZoneStatus, error) {
+	if !c.IsZoneAllowed(zone) {
+		return nil, fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	statuses, err := c.zoneStatus(ctx, normalizeZoneName(zone))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of zone %s: %w", zone, err)
+	}
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrZoneNotFound, zone)
+	}
+
+	return &statuses[0], nil
+}
+
+// GetZoneStatuses returns the status of every allowed zone
+func (c *Client) GetZoneStatuses(ctx context.Context) ([]ZoneStatus, error) {
+	statuses, err := c.zoneStatus(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zone status: %w", err)
+	}
+
+	allowed := statuses[:0]
+	for _, status := range statuses {
+		if c.IsZoneAllowed(status.Zone) {
+			allowed = append(allowed, status)
+		}
+	}
+
+	return allowed, nil
+}
+
+// splitStatusItems splits the "role: master | serial: 1 | ..." part of a
+// knotc zone-status line into its items
+func splitStatusItems(line string) [][2]string {
+	var items [][2]string
+	for _, part := range strings.Split(line, "|") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			continue
+		}
+		items = append(items, [2]string{strings.TrimSpace(name), strings.TrimSpace(value)})
+	}
+	return items
+}
@@ -0,0 +1,287 @@
+package knot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SyncRequest is the desired state of a zone, or of the names at and below
+// Subtree. Every RRset in scope that is not listed is removed, except the
+// SOA and records maintained by the DNSSEC signer.
+type SyncRequest struct {
+	Subtree string  `json:"subtree,omitempty"`
+	RRSets  []RRSet `json:"rrsets" binding:"required"`
+
+	// Fingerprint, if set, makes an apply fail unless the plan computed
+	// against the current zone has the same fingerprint
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// RRSetChange is an RRset whose TTL or values change
+type RRSetChange struct {
+	Before RRSet `json:"before"`
+	After  RRSet `json:"after"`
+}
+
+// RRSetDiff lists the RRsets that differ between the current and the
+// desired state, sorted by owner and type
+type RRSetDiff struct {
+	Adds      []RRSet       `json:"adds"`
+	Removes   []RRSet       `json:"removes"`
+	Changes   []RRSetChange `json:"changes"`
+	Unchanged int           `json:"unchanged"`
+}
+
+// SyncPlan is the diff a SyncRequest results in. Its fingerprint changes
+// whenever the diff does, so it identifies the plan a user has reviewed.
+type SyncPlan struct {
+	Zone    string `json:"zone"`
+	Subtree string `json:"subtree"`
+	RRSetDiff
+	Fingerprint string `json:"fingerprint"`
+	Applied     bool   `json:"applied"`
+}
+
+// rrsetKey identifies an RRset by its absolute owner and type
+type rrsetKey struct {
+	owner      string
+	recordType RecordType
+}
+
+// PlanSync computes the changes that would make a zone or subtree match
+// the request, without making them
+func (c *Client) PlanSync(ctx context.Context, zone string, req *SyncRequest) (*SyncPlan, error) {
+	if !c.IsZoneAllowed(zone) {
+		return nil, fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	plan, _, err := c.planSync(ctx, zone, req)
+	return plan, err
+}
+
+// ApplySync makes a zone or subtree match the request in a single
+// transaction. With a fingerprint in the request nothing is changed unless
+// the plan is still the one that fingerprint was taken from.
+func (c *Client) ApplySync(ctx context.Context, zone string, req *SyncRequest) (*SyncPlan, error) {
+	if !c.IsZoneAllowed(zone) {
+		return nil, fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	plan, changes, err := c.planSync(ctx, zone, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Fingerprint != "" && req.Fingerprint != plan.Fingerprint {
+		return nil, fmt.Errorf("%w: expected plan %s, zone now yields plan %s", ErrPlanStale, req.Fingerprint, plan.Fingerprint)
+	}
+
+	if len(changes) > 0 {
+		err = c.transaction(ctx, zone, func(t *txn) error {
+			for _, change := range changes {
+				if err := t.apply(change); err != nil {
+					return fmt.Errorf("failed to sync %s %s in zone %s: %w", change.Name, change.Type, zone, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	plan.Applied = dryRunFrom(ctx) == nil
+	c.logWrite(ctx, "Synced %s in zone %s: %d added, %d changed, %d removed",
+		plan.Subtree, zone, len(plan.Adds), len(plan.Changes), len(plan.Removes))
+	return plan, nil
+}
+
+// planSync validates a sync request and diffs it against the zone
+func (c *Client) planSync(ctx context.Context, zone string, req *SyncRequest) (*SyncPlan, []Change, error) {
+	root, err := ownerName(zone, req.Subtree)
+	if err != nil {
+		return nil, nil, invalidField("subtree", err)
+	}
+
+	desired := make(map[rrsetKey]*RRSet, len(req.RRSets))
+	for i := range req.RRSets {
+		rrset := req.RRSets[i]
+		rrset.Type = RecordType(strings.ToUpper(string(rrset.Type)))
+
+		owner, err := ownerName(zone, rrset.Name)
+		if err != nil {
+			return nil, nil, invalidItem("rrsets", i, err)
+		}
+		if !inZone(root, owner) {
+			return nil, nil, invalidItem("rrsets", i, invalidFieldf("name", "invalid name: %s is outside subtree %s", owner, root))
+		}
+		if err := rrset.Validate(); err != nil {
+			return nil, nil, invalidItem("rrsets", i, err)
+		}
+
+		key := rrsetKey{owner: owner, recordType: rrset.Type}
+		if desired[key] != nil {
+			return nil, nil, invalidItem("rrsets", i, invalidFieldf("name", "duplicate RRset: %s %s", owner, rrset.Type))
+		}
+		qualifyRRSet(zone, owner, &rrset)
+		desired[key] = &rrset
+	}
+
+	records, err := c.FindRecords(ctx, zone, "", "")
+	if err != nil {
+		return nil, nil, err
+	}
+	current := groupRRSets(records)
+	for key := range current {
+		if !inZone(root, key.owner) {
+			delete(current, key)
+		}
+	}
+
+	diff := diffRRSets(current, desired, true)
+	plan := &SyncPlan{
+		Zone:      strings.ToLower(normalizeZoneName(zone)),
+		Subtree:   root,
+		RRSetDiff: *diff,
+	}
+	plan.Fingerprint = plan.fingerprint()
+
+	return plan, diff.toChanges(), nil
+}
+
+// fingerprint hashes the scope and the differences of the plan
+func (p *SyncPlan) fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", p.Zone, p.Subtree)
+	for _, rrset := range p.Adds {
+		fmt.Fprintf(h, "+ %s\n", rrsetLine(rrset))
+	}
+	for _, rrset := range p.Removes {
+		fmt.Fprintf(h, "- %s\n", rrsetLine(rrset))
+	}
+	for _, change := range p.Changes {
+		fmt.Fprintf(h, "~ %s > %s\n", rrsetLine(change.Before), rrsetLine(change.After))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// rrsetLine renders an RRset on one line with its values sorted
+func rrsetLine(rrset RRSet) string {
+	values := append([]string(nil), rrset.Values...)
+	sort.Strings(values)
+	return strings.Join([]string{rrset.FQDN, string(rrset.Type), strconv.FormatUint(uint64(rrset.TTL), 10), strings.Join(values, "|")}, " ")
+}
+
+// groupRRSets groups records as returned by FindRecords into RRsets keyed
+// by owner and type
+func groupRRSets(records []DNSRecord) map[rrsetKey]*RRSet {
+	rrsets := make(map[rrsetKey]*RRSet)
+	for _, record := range records {
+		key := rrsetKey{owner: strings.ToLower(record.FQDN), recordType: record.Type}
+		rrset, ok := rrsets[key]
+		if !ok {
+			rrset = &RRSet{
+				Name:        record.Name,
+				FQDN:        record.FQDN,
+				NameUnicode: record.NameUnicode,
+				FQDNUnicode: record.FQDNUnicode,
+				Type:        record.Type,
+				TTL:         record.TTL,
+			}
+			rrsets[key] = rrset
+		}
+		rrset.Values = append(rrset.Values, record.RData())
+	}
+	return rrsets
+}
+
+// diffRRSets compares the desired RRsets with the current ones. With prune,
+// current RRsets that are not desired are removed, except the SOA and the
+// records maintained by the DNSSEC signer.
+func diffRRSets(current, desired map[rrsetKey]*RRSet, prune bool) *RRSetDiff {
+	diff := &RRSetDiff{Adds: []RRSet{}, Removes: []RRSet{}, Changes: []RRSetChange{}}
+
+	for _, key := range sortedRRSetKeys(desired) {
+		want := desired[key]
+		have, ok := current[key]
+		switch {
+		case !ok:
+			diff.Adds = append(diff.Adds, *want)
+		case have.TTL != want.TTL || !sameValues(have.Values, want.Values):
+			diff.Changes = append(diff.Changes, RRSetChange{Before: *have, After: *want})
+		default:
+			diff.Unchanged++
+		}
+	}
+
+	if prune {
+		for _, key := range sortedRRSetKeys(current) {
+			if _, ok := desired[key]; ok || key.recordType == RecordTypeSOA || signerTypes[key.recordType] {
+				continue
+			}
+			diff.Removes = append(diff.Removes, *current[key])
+		}
+	}
+
+	return diff
+}
+
+// toChanges returns the changes that turn the current RRsets into the
+// desired ones
+func (d *RRSetDiff) toChanges() []Change {
+	var changes []Change
+	for _, rrset := range d.Removes {
+		changes = append(changes, Change{Op: ChangeOpRemove, Name: rrset.FQDN, Type: rrset.Type})
+	}
+	for _, rrset := range d.Adds {
+		changes = append(changes, replaceChange(rrset))
+	}
+	for _, change := range d.Changes {
+		changes = append(changes, replaceChange(change.After))
+	}
+	return changes
+}
+
+// replaceChange returns the change that sets an RRset to rrset
+func replaceChange(rrset RRSet) Change {
+	return Change{Op: ChangeOpReplace, Name: rrset.FQDN, Type: rrset.Type, TTL: rrset.TTL, Values: rrset.Values}
+}
+
+// sortedRRSetKeys returns the keys of rrsets in canonical order of their
+// owners and then by type
+func sortedRRSetKeys(rrsets map[rrsetKey]*RRSet) []rrsetKey {
+	keys := make([]rrsetKey, 0, len(rrsets))
+	for key := range rrsets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].owner != keys[j].owner {
+			return canonicalLess(keys[i].owner, keys[j].owner)
+		}
+		return keys[i].recordType < keys[j].recordType
+	})
+	return keys
+}
+
+// sameValues reports whether a and b hold the same values in any order
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, value := range a {
+		if !containsValue(b, value) {
+			return false
+		}
+	}
+	return true
+}
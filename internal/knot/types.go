@@ -19,15 +19,49 @@ const (
 	RecordTypeTXT   RecordType = "TXT"
 	RecordTypeNS    RecordType = "NS"
 	RecordTypeSOA   RecordType = "SOA"
+	RecordTypeSRV   RecordType = "SRV"
+	RecordTypeCAA   RecordType = "CAA"
+	RecordTypeTLSA  RecordType = "TLSA"
+	RecordTypeSSHFP RecordType = "SSHFP"
+	RecordTypeSVCB  RecordType = "SVCB"
+	RecordTypeHTTPS RecordType = "HTTPS"
+
+	// DNSSEC records maintained by knotd's signer
+	RecordTypeDNSKEY  RecordType = "DNSKEY"
+	RecordTypeCDS     RecordType = "CDS"
+	RecordTypeCDNSKEY RecordType = "CDNSKEY"
+	RecordTypeDS      RecordType = "DS"
 )
 
 // DNSRecord represents a DNS record
 type DNSRecord struct {
 	Name     string     `json:"name" yaml:"name"`
+	FQDN     string     `json:"fqdn,omitempty" yaml:"fqdn,omitempty"`
 	Type     RecordType `json:"type" yaml:"type"`
 	TTL      uint32     `json:"ttl" yaml:"ttl"`
 	Data     string     `json:"data" yaml:"data"`
 	Priority *uint16    `json:"priority,omitempty" yaml:"priority,omitempty"` // For MX records
+
+	// Unicode forms of names holding IDN A-labels, only set in responses
+	NameUnicode string `json:"name_unicode,omitempty" yaml:"name_unicode,omitempty"`
+	FQDNUnicode string `json:"fqdn_unicode,omitempty" yaml:"fqdn_unicode,omitempty"`
+	DataUnicode string `json:"data_unicode,omitempty" yaml:"data_unicode,omitempty"`
+
+	TypedData `yaml:",inline"`
+}
+
+// RRSet represents all records sharing a name and type. Values hold the
+// record data in presentation format, e.g. "10 mail.example.com." for MX.
+type RRSet struct {
+	Name   string     `json:"name" yaml:"name"`
+	FQDN   string     `json:"fqdn,omitempty" yaml:"fqdn,omitempty"`
+	Type   RecordType `json:"type" yaml:"type"`
+	TTL    uint32     `json:"ttl" yaml:"ttl"`
+	Values []string   `json:"values" yaml:"values"`
+
+	// Unicode forms of names holding IDN A-labels, only set in responses
+	NameUnicode string `json:"name_unicode,omitempty" yaml:"name_unicode,omitempty"`
+	FQDNUnicode string `json:"fqdn_unicode,omitempty" yaml:"fqdn_unicode,omitempty"`
 }
 
 // Zone represents a DNS zone
@@ -41,15 +75,54 @@ type CreateRecordRequest struct {
 	Name     string     `json:"name" binding:"required"`
 	Type     RecordType `json:"type" binding:"required"`
 	TTL      uint32     `json:"ttl"`
-	Data     string     `json:"data" binding:"required"`
+	Data     string     `json:"data"` // optional when typed fields are given
 	Priority *uint16    `json:"priority,omitempty"`
+	TypedData
 }
 
-// UpdateRecordRequest represents a request to update a DNS record
+// UpdateRecordRequest represents a request to update a DNS record. Match
+// selects the value to update when the RRset holds more than one.
 type UpdateRecordRequest struct {
+	Match    *string `json:"match,omitempty"`
 	TTL      *uint32 `json:"ttl,omitempty"`
 	Data     *string `json:"data,omitempty"`
 	Priority *uint16 `json:"priority,omitempty"`
+	TypedData
+}
+
+// ReplaceRRSetRequest represents a request to replace a whole RRset
+type ReplaceRRSetRequest struct {
+	TTL    uint32   `json:"ttl"`
+	Values []string `json:"values" binding:"required"`
+}
+
+// RRSetValueRequest represents a request to add a single value to an RRset
+type RRSetValueRequest struct {
+	TTL   uint32 `json:"ttl"`
+	Value string `json:"value" binding:"required"`
+}
+
+// ChangeOp is the kind of a single operation in a change set
+type ChangeOp string
+
+const (
+	ChangeOpAdd     ChangeOp = "add"     // Add values to an RRset
+	ChangeOpRemove  ChangeOp = "remove"  // Remove values, or the whole RRset if none are given
+	ChangeOpReplace ChangeOp = "replace" // Replace the whole RRset
+)
+
+// Change is a single operation of a change set
+type Change struct {
+	Op     ChangeOp   `json:"op" yaml:"op" binding:"required"`
+	Name   string     `json:"name" yaml:"name" binding:"required"`
+	Type   RecordType `json:"type" yaml:"type" binding:"required"`
+	TTL    uint32     `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	Values []string   `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+// ChangesRequest represents an ordered list of changes applied atomically
+type ChangesRequest struct {
+	Changes []Change `json:"changes" binding:"required"`
 }
 
 // ValidRecordTypes returns a list of supported record types
@@ -62,6 +135,13 @@ func ValidRecordTypes() []RecordType {
 		RecordTypeMX,
 		RecordTypeTXT,
 		RecordTypeNS,
+		RecordTypeSRV,
+		RecordTypeCAA,
+		RecordTypeTLSA,
+		RecordTypeSSHFP,
+		RecordTypeDS,
+		RecordTypeSVCB,
+		RecordTypeHTTPS,
 	}
 }
 
@@ -81,12 +161,25 @@ func IsValidRecordType(recordType string) bool {
 func (r *DNSRecord) Validate() error {
 	// Validate name
 	if r.Name == "" {
-		return fmt.Errorf("record name cannot be empty")
+		return invalidFieldf("name", "record name cannot be empty")
 	}
 
-	// Validate type
+	// Validate type; every check below compares it in upper case
+	r.Type = RecordType(strings.ToUpper(string(r.Type)))
 	if !IsValidRecordType(string(r.Type)) {
-		return fmt.Errorf("invalid record type: %s", r.Type)
+		return invalidFieldf("type", "invalid record type: %s", r.Type)
+	}
+
+	if isWildcard(r.Name) && !wildcardAllowed(r.Type) {
+		return invalidFieldf("name", "invalid name: %s records cannot have a wildcard owner", r.Type)
+	}
+
+	if r.Data == "" && r.TypedData.isEmpty() {
+		return invalidFieldf("data", "invalid record data: data cannot be empty")
+	}
+
+	if r.Type == RecordTypeMX && r.Priority == nil {
+		return invalidFieldf("priority", "priority is required for MX record")
 	}
 
 	// Validate TTL
@@ -96,7 +189,7 @@ func (r *DNSRecord) Validate() error {
 
 	// Validate data based on record type
 	if err := r.validateData(); err != nil {
-		return fmt.Errorf("invalid record data: %w", err)
+		return invalidField("data", fmt.Errorf("invalid record data: %w", err))
 	}
 
 	return nil
@@ -117,25 +210,113 @@ func (r *DNSRecord) validateData() error {
 		if r.Data == "" {
 			return fmt.Errorf("data cannot be empty for %s record", r.Type)
 		}
+		target, err := ToASCII(r.Data)
+		if err != nil {
+			return err
+		}
+		r.Data = target
 		// Ensure FQDN ends with dot
 		if !strings.HasSuffix(r.Data, ".") {
 			r.Data += "."
 		}
 	case RecordTypeMX:
-		if r.Priority == nil {
-			return fmt.Errorf("priority is required for MX record")
+		target, err := targetToASCII(r.Data)
+		if err != nil {
+			return err
 		}
+		r.Data = target
 		if !strings.HasSuffix(r.Data, ".") {
 			r.Data += "."
 		}
-	case RecordTypeTXT:
-		if r.Data == "" {
-			return fmt.Errorf("data cannot be empty for TXT record")
+	case RecordTypeTXT, RecordTypeSRV, RecordTypeCAA, RecordTypeTLSA, RecordTypeSSHFP, RecordTypeDS, RecordTypeSVCB, RecordTypeHTTPS:
+		// Typed fields take precedence over data
+		fields, err := r.fields()
+		if err != nil {
+			return err
+		}
+		if err := fields.validate(); err != nil {
+			return err
+		}
+		r.Data = fields.String()
+	}
+
+	return nil
+}
+
+// Validate validates every value of the RRset and normalizes them to the
+// form KnotDNS reports them in
+func (s *RRSet) Validate() error {
+	s.Type = RecordType(strings.ToUpper(string(s.Type)))
+	if len(s.Values) == 0 {
+		return invalidFieldf("values", "RRset must contain at least one value")
+	}
+
+	seen := make(map[string]bool)
+	values := make([]string, 0, len(s.Values))
+	for _, value := range s.Values {
+		record, err := s.record(value)
+		if err != nil {
+			return invalidField("values", err)
+		}
+		s.TTL = record.TTL
+		if rdata := record.RData(); !seen[rdata] {
+			seen[rdata] = true
+			values = append(values, rdata)
+		}
+	}
+	s.Values = values
+
+	return nil
+}
+
+// record builds and validates the record holding a single RRset value
+func (s *RRSet) record(value string) (*DNSRecord, error) {
+	record := &DNSRecord{Name: s.Name, Type: s.Type, TTL: s.TTL, Data: strings.TrimSpace(value)}
+
+	// MX values carry their priority in front of the exchange
+	if s.Type == RecordTypeMX {
+		parsed, err := newRecord(s.Name, "0", string(s.Type), value)
+		if err != nil {
+			return nil, err
 		}
-		// Ensure TXT data is properly quoted
-		if !strings.HasPrefix(r.Data, "\"") || !strings.HasSuffix(r.Data, "\"") {
-			r.Data = fmt.Sprintf("\"%s\"", strings.Trim(r.Data, "\""))
+		record.Priority = parsed.Priority
+		record.Data = parsed.Data
+	}
+
+	if err := record.Validate(); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// Validate validates a change and normalizes its values. The TTL is left
+// as given so that zero can mean "keep the current TTL".
+func (ch *Change) Validate() error {
+	switch ch.Op {
+	case ChangeOpAdd, ChangeOpReplace:
+		if len(ch.Values) == 0 {
+			return invalidFieldf("values", "%s requires at least one value", ch.Op)
 		}
+	case ChangeOpRemove:
+	default:
+		return invalidFieldf("op", "invalid operation: %s", ch.Op)
+	}
+
+	if ch.Name == "" {
+		return invalidFieldf("name", "record name cannot be empty")
+	}
+	ch.Type = RecordType(strings.ToUpper(string(ch.Type)))
+	if !IsValidRecordType(string(ch.Type)) {
+		return invalidFieldf("type", "invalid record type: %s", ch.Type)
+	}
+
+	if len(ch.Values) > 0 {
+		rrset := &RRSet{Name: ch.Name, Type: ch.Type, TTL: ch.TTL, Values: ch.Values}
+		if err := rrset.Validate(); err != nil {
+			return err
+		}
+		ch.Values = rrset.Values
 	}
 
 	return nil
@@ -159,42 +340,60 @@ func (r *DNSRecord) ToKnotFormat() string {
 	return strings.Join(parts, " ")
 }
 
+// RData returns the record data in KnotDNS presentation format
+func (r *DNSRecord) RData() string {
+	if r.Type == RecordTypeMX && r.Priority != nil {
+		return strconv.FormatUint(uint64(*r.Priority), 10) + " " + r.Data
+	}
+	return r.Data
+}
+
 // ParseKnotRecord parses a record from KnotDNS output format
 func ParseKnotRecord(line string) (*DNSRecord, error) {
-	// Remove brackets and extra whitespace
-	line = strings.TrimSpace(line)
-	if strings.HasPrefix(line, "[") && strings.Contains(line, "]") {
-		// Extract content after the bracket: [zone] record_data
-		bracketEnd := strings.Index(line, "]")
-		if bracketEnd != -1 && bracketEnd < len(line)-1 {
-			line = strings.TrimSpace(line[bracketEnd+1:])
-		}
+	_, owner, ttl, recordType, rdata, err := splitKnotRecord(line)
+	if err != nil {
+		return nil, err
 	}
 
-	parts := strings.Fields(line)
-	if len(parts) < 4 {
-		return nil, fmt.Errorf("invalid record format: %s", line)
-	}
+	return newRecord(owner, ttl, recordType, rdata)
+}
+
+// splitKnotRecord splits a KnotDNS output line into zone, owner, TTL, type
+// and the raw record data
+func splitKnotRecord(line string) (zone, owner, ttl, recordType, rdata string, err error) {
+	// Extract content after the bracket: [zone] record_data
+	zone, line = splitZonePrefix(strings.TrimSpace(line))
 
 	// KnotDNS format: name TTL class type data
 	// We need to handle the class field (usually "IN")
-	var name, ttlStr, recordType string
-	var dataStart int
+	owner, rest := nextField(line)
+	ttl, rest = nextField(rest)
+	recordType, rest = nextField(rest)
+	if (recordType == "IN" || recordType == "CH" || recordType == "HS") && len(strings.Fields(rest)) >= 2 {
+		recordType, rest = nextField(rest)
+	}
 
-	name = parts[0]
-	ttlStr = parts[1]
+	if owner == "" || ttl == "" || recordType == "" || rest == "" {
+		return "", "", "", "", "", fmt.Errorf("invalid record format: %s", line)
+	}
 
-	// Check if we have class field (IN)
-	if len(parts) >= 5 && (parts[2] == "IN" || parts[2] == "CH" || parts[2] == "HS") {
-		recordType = parts[3]
-		dataStart = 4
-	} else {
-		recordType = parts[2]
-		dataStart = 3
+	return zone, owner, ttl, recordType, rest, nil
+}
+
+// nextField returns the first whitespace separated field of s and the
+// remainder with leading whitespace removed
+func nextField(s string) (string, string) {
+	s = strings.TrimLeft(s, " \t")
+	if i := strings.IndexAny(s, " \t"); i != -1 {
+		return s[:i], strings.TrimLeft(s[i:], " \t")
 	}
+	return s, ""
+}
 
+// newRecord builds a record from its owner, TTL, type and raw record data
+func newRecord(owner, ttlStr, recordType, rdata string) (*DNSRecord, error) {
 	record := &DNSRecord{
-		Name: name,
+		Name: owner,
 		Type: RecordType(recordType),
 	}
 
@@ -208,44 +407,52 @@ func ParseKnotRecord(line string) (*DNSRecord, error) {
 	// Handle different record types
 	switch record.Type {
 	case RecordTypeMX:
-		if len(parts) < dataStart+2 {
-			return nil, fmt.Errorf("invalid MX record format: %s", line)
+		priority, data := nextField(rdata)
+		if data == "" {
+			return nil, fmt.Errorf("invalid MX record data: %s", rdata)
 		}
-		priority, err := strconv.ParseUint(parts[dataStart], 10, 16)
+		p, err := strconv.ParseUint(priority, 10, 16)
 		if err != nil {
-			return nil, fmt.Errorf("invalid MX priority: %s", parts[dataStart])
+			return nil, fmt.Errorf("invalid MX priority: %s", priority)
 		}
-		p := uint16(priority)
-		record.Priority = &p
-		record.Data = strings.Join(parts[dataStart+1:], " ")
+		prio := uint16(p)
+		record.Priority = &prio
+		record.Data = strings.TrimSpace(data)
 	default:
-		if len(parts) > dataStart {
-			record.Data = strings.Join(parts[dataStart:], " ")
+		record.Data = strings.TrimSpace(rdata)
+
+		// Fill typed fields, keeping only the raw data if KnotDNS reports
+		// something we cannot parse
+		if isStructuredType(record.Type) {
+			if fields, err := record.fields(); err == nil {
+				record.Data = fields.String()
+			} else {
+				record.TypedData = TypedData{}
+			}
 		}
 	}
 
 	return record, nil
 }
 
+// recordFromCtl builds a record from a zone-read control data unit
+func recordFromCtl(data ctlData) (*DNSRecord, error) {
+	return newRecord(data[ctlIdxOwner], data[ctlIdxTTL], data[ctlIdxType], data[ctlIdxData])
+}
+
 // Validate validates a create record request
 func (r *CreateRecordRequest) Validate() error {
-	record := &DNSRecord{
-		Name:     r.Name,
-		Type:     r.Type,
-		TTL:      r.TTL,
-		Data:     r.Data,
-		Priority: r.Priority,
-	}
-	return record.Validate()
+	return r.ToRecord().Validate()
 }
 
 // ToRecord converts CreateRecordRequest to DNSRecord
 func (r *CreateRecordRequest) ToRecord() *DNSRecord {
 	return &DNSRecord{
-		Name:     r.Name,
-		Type:     r.Type,
-		TTL:      r.TTL,
-		Data:     r.Data,
-		Priority: r.Priority,
+		Name:      r.Name,
+		Type:      r.Type,
+		TTL:       r.TTL,
+		Data:      r.Data,
+		Priority:  r.Priority,
+		TypedData: r.TypedData,
 	}
 }
@@ -0,0 +1,97 @@
+package knot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDNSRecordValidateWildcard(t *testing.T) {
+	tests := []struct {
+		name       string
+		owner      string
+		recordType RecordType
+		data       string
+		ok         bool
+	}{
+		{name: "A", owner: "*", recordType: "A", data: "192.0.2.1", ok: true},
+		{name: "CNAME below a label", owner: "*.dev", recordType: "CNAME", data: "www.example.com.", ok: true},
+		{name: "NS", owner: "*", recordType: "NS", data: "ns1.example.com.", ok: false},
+		{name: "lowercase ns", owner: "*", recordType: "ns", data: "ns1.example.com.", ok: false},
+		{name: "mixed case Ds", owner: "*.dev", recordType: "Ds", data: "60485 5 2 D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := &DNSRecord{Name: tt.owner, Type: tt.recordType, TTL: 300, Data: tt.data}
+			err := record.Validate()
+			if tt.ok {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+
+			var kerr *Error
+			if !errors.As(err, &kerr) || kerr.Kind != ErrInvalid || kerr.Field != "name" {
+				t.Errorf("Validate = %v, want an invalid name error", err)
+			}
+		})
+	}
+}
+
+func TestDNSRecordValidateLowercaseType(t *testing.T) {
+	priority := uint16(10)
+	tests := []struct {
+		name     string
+		record   DNSRecord
+		field    string // empty if the record is valid
+		wantType RecordType
+	}{
+		{name: "valid a", record: DNSRecord{Name: "www", Type: "a", Data: "192.0.2.1"}, wantType: RecordTypeA},
+		{name: "a with invalid address", record: DNSRecord{Name: "www", Type: "a", Data: "not-an-ip"}, field: "data"},
+		{name: "aaaa with IPv4 address", record: DNSRecord{Name: "www", Type: "aaaa", Data: "192.0.2.1"}, field: "data"},
+		{name: "mx without priority", record: DNSRecord{Name: "@", Type: "mx", Data: "mail.example.net"}, field: "priority"},
+		{
+			name:     "mx with priority",
+			record:   DNSRecord{Name: "@", Type: "mx", Data: "mail.example.net", Priority: &priority},
+			wantType: RecordTypeMX,
+		},
+		{name: "srv with missing fields", record: DNSRecord{Name: "_sip._tcp", Type: "srv", Data: "sip.example.com."}, field: "data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := tt.record
+			err := record.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				if record.Type != tt.wantType {
+					t.Errorf("type = %s, want %s", record.Type, tt.wantType)
+				}
+				return
+			}
+
+			var kerr *Error
+			if !errors.As(err, &kerr) || kerr.Kind != ErrInvalid || kerr.Field != tt.field {
+				t.Errorf("Validate = %v, want an invalid %s error", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestRRSetValidateLowercaseType(t *testing.T) {
+	rrset := &RRSet{Name: "@", Type: "mx", TTL: 300, Values: []string{"10 mail.example.net"}}
+	if err := rrset.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if rrset.Type != RecordTypeMX || rrset.Values[0] != "10 mail.example.net." {
+		t.Errorf("RRset = %s %q, want MX \"10 mail.example.net.\"", rrset.Type, rrset.Values)
+	}
+
+	rrset = &RRSet{Name: "www", Type: "a", TTL: 300, Values: []string{"not-an-ip"}}
+	if err := rrset.Validate(); err == nil {
+		t.Errorf("Validate of a with invalid address succeeded, want an error")
+	}
+}
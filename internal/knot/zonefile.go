@@ -0,0 +1,338 @@
+package knot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// zoneFileField is the request field reported for errors in a zone file
+const zoneFileField = "zone_file"
+
+// signerTypes are maintained by knotd's DNSSEC signer. They are skipped
+// when importing a zone file and left alone when replacing a zone.
+var signerTypes = map[RecordType]bool{
+	RecordTypeDNSKEY:  true,
+	RecordTypeCDS:     true,
+	RecordTypeCDNSKEY: true,
+	"RRSIG":           true,
+	"NSEC":            true,
+	"NSEC3":           true,
+	"NSEC3PARAM":      true,
+}
+
+// zoneFileEntry is a single resource record read from a zone file, with
+// its owner and the names in its data made absolute
+type zoneFileEntry struct {
+	line  int
+	owner string
+	ttl   uint32
+	typ   RecordType
+	rdata string
+}
+
+// zoneFileLine is a logical line of a zone file: its tokens, with lines
+// joined inside parentheses, and whether it started with blank space
+type zoneFileLine struct {
+	number int
+	blank  bool
+	tokens []string
+}
+
+// parseZoneFile reads a zone file in RFC 1035 master file format. It
+// understands $ORIGIN and $TTL, relative names, "@", omitted owners, TTLs
+// and classes, parentheses spanning lines, quoted strings and comments.
+func parseZoneFile(r io.Reader, origin string) ([]zoneFileEntry, error) {
+	origin = strings.ToLower(normalizeZoneName(origin))
+	lines, err := splitZoneFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []zoneFileEntry
+	var owner string
+	var defaultTTL, lastTTL uint32
+	var hasDefaultTTL, hasLastTTL bool
+
+	for _, line := range lines {
+		tokens := line.tokens
+		lineErr := func(format string, args ...any) error {
+			return invalidFieldf(zoneFileField, "line %d: "+format, append([]any{line.number}, args...)...)
+		}
+
+		if !line.blank && strings.HasPrefix(tokens[0], "$") {
+			switch strings.ToUpper(tokens[0]) {
+			case "$ORIGIN":
+				if len(tokens) != 2 {
+					return nil, lineErr("$ORIGIN takes a single name")
+				}
+				origin = qualifyName(tokens[1], origin)
+			case "$TTL":
+				if len(tokens) != 2 {
+					return nil, lineErr("$TTL takes a single TTL")
+				}
+				ttl, err := parseTTL(tokens[1])
+				if err != nil {
+					return nil, lineErr("%v", err)
+				}
+				defaultTTL, hasDefaultTTL = ttl, true
+			default:
+				return nil, lineErr("%s is not supported", tokens[0])
+			}
+			continue
+		}
+
+		if !line.blank {
+			owner = qualifyName(tokens[0], origin)
+			tokens = tokens[1:]
+		} else if owner == "" {
+			return nil, lineErr("record without owner")
+		}
+
+		// TTL and class may come in either order before the type
+		ttl, hasTTL := uint32(0), false
+		for i := 0; i < 2 && len(tokens) > 0; i++ {
+			if isClass(tokens[0]) {
+				if !strings.EqualFold(tokens[0], "IN") {
+					return nil, lineErr("class %s is not supported", tokens[0])
+				}
+				tokens = tokens[1:]
+				continue
+			}
+			if !hasTTL && tokens[0] != "" && isDigit(tokens[0][0]) {
+				var err error
+				if ttl, err = parseTTL(tokens[0]); err != nil {
+					return nil, lineErr("%v", err)
+				}
+				hasTTL = true
+				tokens = tokens[1:]
+			}
+		}
+		if len(tokens) < 2 {
+			return nil, lineErr("record needs a type and data")
+		}
+
+		switch {
+		case hasTTL:
+			lastTTL, hasLastTTL = ttl, true
+		case hasDefaultTTL:
+			ttl = defaultTTL
+		case hasLastTTL:
+			ttl = lastTTL
+		default:
+			return nil, lineErr("record has no TTL and no $TTL is set")
+		}
+
+		recordType := RecordType(strings.ToUpper(tokens[0]))
+		entries = append(entries, zoneFileEntry{
+			line:  line.number,
+			owner: owner,
+			ttl:   ttl,
+			typ:   recordType,
+			rdata: strings.Join(qualifyRData(recordType, tokens[1:], origin), " "),
+		})
+	}
+
+	return entries, nil
+}
+
+// splitZoneFile splits a zone file into logical lines of tokens. Quoted
+// strings and escapes are kept as they are; comments are dropped.
+func splitZoneFile(r io.Reader) ([]zoneFileLine, error) {
+	reader := bufio.NewReader(r)
+
+	var lines []zoneFileLine
+	var current zoneFileLine
+	var token strings.Builder
+	number, depth := 1, 0
+	inQuotes, escaped, inToken, comment, lineStart := false, false, false, false, true
+
+	endToken := func() {
+		if inToken {
+			current.tokens = append(current.tokens, token.String())
+			token.Reset()
+			inToken = false
+		}
+	}
+
+	for {
+		r, _, err := reader.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, invalidField(zoneFileField, err)
+		}
+
+		if lineStart {
+			current = zoneFileLine{number: number, blank: r == ' ' || r == '\t'}
+			lineStart = false
+		}
+
+		switch {
+		case comment && r != '\n':
+			continue
+		case escaped:
+			token.WriteRune(r)
+			escaped = false
+			if r == '\n' {
+				number++
+			}
+			continue
+		case r == '\\':
+			token.WriteRune(r)
+			inToken, escaped = true, true
+			continue
+		case inQuotes:
+			token.WriteRune(r)
+			if r == '"' {
+				inQuotes = false
+			}
+			if r == '\n' {
+				number++
+			}
+			continue
+		}
+
+		switch r {
+		case '"':
+			token.WriteRune(r)
+			inToken, inQuotes = true, true
+		case ';':
+			endToken()
+			comment = true
+		case '(':
+			endToken()
+			depth++
+		case ')':
+			endToken()
+			if depth == 0 {
+				return nil, invalidFieldf(zoneFileField, "line %d: unbalanced parentheses", number)
+			}
+			depth--
+		case ' ', '\t', '\r':
+			endToken()
+		case '\n':
+			endToken()
+			comment = false
+			number++
+			if depth == 0 {
+				if len(current.tokens) > 0 {
+					lines = append(lines, current)
+				}
+				lineStart = true
+			}
+		default:
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if inQuotes {
+		return nil, invalidFieldf(zoneFileField, "line %d: unterminated quoted string", current.number)
+	}
+	if depth > 0 {
+		return nil, invalidFieldf(zoneFileField, "line %d: unbalanced parentheses", current.number)
+	}
+	endToken()
+	if !lineStart && len(current.tokens) > 0 {
+		lines = append(lines, current)
+	}
+
+	return lines, nil
+}
+
+// qualifyName makes a name from a zone file absolute. "@" is the origin.
+func qualifyName(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return strings.ToLower(name)
+	case origin == ".":
+		return strings.ToLower(name) + "."
+	default:
+		return strings.ToLower(name) + "." + origin
+	}
+}
+
+// qualifyRData makes the domain names in record data absolute and quotes
+// bare TXT strings, so that the data no longer depends on the origin
+func qualifyRData(recordType RecordType, fields []string, origin string) []string {
+	var names []int
+	switch recordType {
+	case RecordTypeCNAME, RecordTypeNS, RecordTypePTR:
+		names = []int{0}
+	case RecordTypeMX, RecordTypeSVCB, RecordTypeHTTPS:
+		names = []int{1}
+	case RecordTypeSRV:
+		names = []int{3}
+	case RecordTypeSOA:
+		names = []int{0, 1}
+	case RecordTypeTXT:
+		for i, field := range fields {
+			if !strings.HasPrefix(field, `"`) {
+				fields[i] = `"` + field + `"`
+			}
+		}
+	}
+
+	for _, i := range names {
+		if i < len(fields) {
+			fields[i] = qualifyName(fields[i], origin)
+		}
+	}
+	return fields
+}
+
+// isClass reports whether token is a DNS class mnemonic
+func isClass(token string) bool {
+	switch strings.ToUpper(token) {
+	case "IN", "CH", "CS", "HS":
+		return true
+	}
+	return false
+}
+
+// parseTTL parses a TTL in seconds or in BIND's unit notation such as 1h30m
+func parseTTL(s string) (uint32, error) {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(n), nil
+	}
+
+	var total, n uint64
+	digits := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isDigit(c) {
+			n = n*10 + uint64(c-'0')
+			digits = true
+			continue
+		}
+		if !digits {
+			return 0, fmt.Errorf("invalid TTL: %s", s)
+		}
+		switch c | 0x20 {
+		case 's':
+			total += n
+		case 'm':
+			total += n * 60
+		case 'h':
+			total += n * 3600
+		case 'd':
+			total += n * 86400
+		case 'w':
+			total += n * 604800
+		default:
+			return 0, fmt.Errorf("invalid TTL: %s", s)
+		}
+		n, digits = 0, false
+	}
+	if digits || total > 1<<31-1 {
+		return 0, fmt.Errorf("invalid TTL: %s", s)
+	}
+
+	return uint32(total), nil
+}
@@ -0,0 +1,131 @@
+package knot
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseZoneFile(t *testing.T) {
+	zoneFile := `$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1 hostmaster (
+		2024010101 ; serial
+		3600 900 604800 300 )
+	IN	NS	ns1
+www	300	A	192.0.2.1
+	IN 600	AAAA	2001:db8::1 ; same owner as above
+Mail.Example.COM.	MX	10 mail
+txt	TXT	"hello world; not a comment" "second"
+bare	TXT	unquoted
+$ORIGIN sub
+host	CNAME	www.example.com.
+alias	IN	CNAME	target
+`
+
+	want := []zoneFileEntry{
+		{line: 3, owner: "example.com.", ttl: 3600, typ: RecordTypeSOA,
+			rdata: "ns1.example.com. hostmaster.example.com. 2024010101 3600 900 604800 300"},
+		{line: 6, owner: "example.com.", ttl: 3600, typ: RecordTypeNS, rdata: "ns1.example.com."},
+		{line: 7, owner: "www.example.com.", ttl: 300, typ: RecordTypeA, rdata: "192.0.2.1"},
+		{line: 8, owner: "www.example.com.", ttl: 600, typ: RecordTypeAAAA, rdata: "2001:db8::1"},
+		{line: 9, owner: "mail.example.com.", ttl: 3600, typ: RecordTypeMX, rdata: "10 mail.example.com."},
+		{line: 10, owner: "txt.example.com.", ttl: 3600, typ: RecordTypeTXT, rdata: `"hello world; not a comment" "second"`},
+		{line: 11, owner: "bare.example.com.", ttl: 3600, typ: RecordTypeTXT, rdata: `"unquoted"`},
+		{line: 13, owner: "host.sub.example.com.", ttl: 3600, typ: RecordTypeCNAME, rdata: "www.example.com."},
+		{line: 14, owner: "alias.sub.example.com.", ttl: 3600, typ: RecordTypeCNAME, rdata: "target.sub.example.com."},
+	}
+
+	got, err := parseZoneFile(strings.NewReader(zoneFile), "example.com")
+	if err != nil {
+		t.Fatalf("parseZoneFile: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("entry %d = %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseZoneFileTTL(t *testing.T) {
+	// Without $TTL, a record without TTL takes the TTL of the one before
+	zoneFile := "a 120 A 192.0.2.1\nb A 192.0.2.2\nc 2m30s A 192.0.2.3\n"
+
+	got, err := parseZoneFile(strings.NewReader(zoneFile), "example.com.")
+	if err != nil {
+		t.Fatalf("parseZoneFile: %v", err)
+	}
+	var ttls []uint32
+	for _, entry := range got {
+		ttls = append(ttls, entry.ttl)
+	}
+	if want := []uint32{120, 120, 150}; !reflect.DeepEqual(ttls, want) {
+		t.Errorf("TTLs = %v, want %v", ttls, want)
+	}
+}
+
+func TestParseZoneFileInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		zoneFile string
+	}{
+		{name: "unbalanced open parenthesis", zoneFile: "@ 300 SOA ns1 host ( 1 2 3 4 5\n"},
+		{name: "unbalanced close parenthesis", zoneFile: "@ 300 A 192.0.2.1 )\n"},
+		{name: "unterminated quote", zoneFile: "@ 300 TXT \"open\n"},
+		{name: "record without owner", zoneFile: "\t300 A 192.0.2.1\n"},
+		{name: "no TTL", zoneFile: "www A 192.0.2.1\n"},
+		{name: "unsupported directive", zoneFile: "$INCLUDE other.zone\n"},
+		{name: "origin without name", zoneFile: "$ORIGIN\n"},
+		{name: "invalid default TTL", zoneFile: "$TTL forever\n"},
+		{name: "other class", zoneFile: "www 300 CH A 192.0.2.1\n"},
+		{name: "missing data", zoneFile: "www 300 A\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseZoneFile(strings.NewReader(tt.zoneFile), "example.com.")
+			if err == nil {
+				t.Fatalf("parseZoneFile succeeded, want an error")
+			}
+
+			var kerr *Error
+			if !errors.As(err, &kerr) || kerr.Kind != ErrInvalid || kerr.Field != zoneFileField {
+				t.Errorf("parseZoneFile error = %v, want an invalid zone_file error", err)
+			}
+		})
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint32
+	}{
+		{"0", 0},
+		{"3600", 3600},
+		{"1h", 3600},
+		{"1h30m", 5400},
+		{"1W", 604800},
+		{"1d2h3m4s", 93784},
+	}
+	for _, tt := range tests {
+		got, err := parseTTL(tt.in)
+		if err != nil {
+			t.Errorf("parseTTL(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTTL(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"h", "1x", "1h5", "-1", "100000w"} {
+		if _, err := parseTTL(in); err == nil {
+			t.Errorf("parseTTL(%q) succeeded, want an error", in)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package knot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ZoneTemplate describes the configuration and initial contents of zones
+// created through the API
+type ZoneTemplate struct {
+	KnotTemplate string   // knot.conf template assigned to new zones, optional
+	TTL          uint32   // TTL of the initial SOA and NS records
+	MName        string   // SOA primary name server, defaults to the first name server
+	RName        string   // SOA responsible mailbox, defaults to hostmaster.<zone>
+	Refresh      uint32   // SOA refresh interval
+	Retry        uint32   // SOA retry interval
+	Expire       uint32   // SOA expire interval
+	Minimum      uint32   // SOA minimum (negative caching) TTL
+	Nameservers  []string // Apex NS records
+}
+
+// CreateZoneRequest represents a request to provision a new zone
+type CreateZoneRequest struct {
+	Zone string `json:"zone" binding:"required"`
+}
+
+// zoneNameRe matches a syntactically valid zone name without trailing dot
+var zoneNameRe = regexp.MustCompile(`^([a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?\.)*[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?$`)
+
+// validateZoneName checks that zone is a valid domain name
+func validateZoneName(zone string) error {
+	name := strings.TrimSuffix(strings.ToLower(zone), ".")
+	if name == "" || len(name) > 253 || !zoneNameRe.MatchString(name) {
+		return invalidFieldf("zone", "invalid zone name: %s", zone)
+	}
+	return nil
+}
+
+// soa returns the initial SOA record data for zone
+func (t ZoneTemplate) soa(zone string) (string, error) {
+	if len(t.Nameservers) == 0 {
+		return "", fmt.Errorf("%w: cannot create %s", ErrZoneTemplateIncomplete, zone)
+	}
+
+	mname := t.MName
+	if mname == "" {
+		mname = t.Nameservers[0]
+	}
+	rname := t.RName
+	if rname == "" {
+		rname = "hostmaster." + zone
+	}
+	serial := time.Now().UTC().Format("20060102") + "01"
+
+	return fmt.Sprintf("%s %s %s %d %d %d %d", normalizeZoneName(mname), normalizeZoneName(rname),
+		serial, t.Refresh, t.Retry, t.Expire, t.Minimum), nil
+}
+
+// hasZone reports whether zone is configured in knotd
+func (c *Client) hasZone(ctx context.Context, zone string) (bool, error) {
+	zones, err := c.GetZones(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	normalizedZone := strings.ToLower(normalizeZoneName(zone))
+	for _, existing := range zones {
+		if strings.ToLower(normalizeZoneName(existing)) == normalizedZone {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// confTransaction runs fn inside a conf-begin/conf-commit pair and aborts
+// the configuration transaction if anything fails
+func (c *Client) confTransaction(ctx context.Context, fn func(cn conn) error) error {
+	c.confMu.Lock()
+	defer c.confMu.Unlock()
+
+	return c.withConn(ctx, func(cn conn) error {
+		if err := c.execute(ctx, cn, ctlData{ctlIdxCmd: "conf-begin"}, nil); err != nil {
+			return fmt.Errorf("failed to begin configuration transaction: %w", err)
+		}
+
+		if err := fn(cn); err != nil {
+			c.cleanup(ctx, cn, ctlData{ctlIdxCmd: "conf-abort"})
+			return err
+		}
+
+		if err := c.execute(ctx, cn, ctlData{ctlIdxCmd: "conf-commit"}, nil); err != nil {
+			c.cleanup(ctx, cn, ctlData{ctlIdxCmd: "conf-abort"})
+			return fmt.Errorf("failed to commit configuration transaction: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// CreateZone adds a zone to the knotd configuration and writes its initial
+// SOA and NS records from the zone template
+func (c *Client) CreateZone(ctx context.Context, zone string) error {
+	if err := validateZoneName(zone); err != nil {
+		return err
+	}
+
+	if !c.IsZoneAllowed(zone) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	normalizedZone := strings.ToLower(normalizeZoneName(zone))
+	soa, err := c.zoneTemplate.soa(normalizedZone)
+	if err != nil {
+		return err
+	}
+
+	exists, err := c.hasZone(ctx, normalizedZone)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", ErrZoneExists, zone)
+	}
+
+	// Add the zone to the configuration
+	err = c.confTransaction(ctx, func(cn conn) error {
+		if err := c.execute(ctx, cn, ctlData{
+			ctlIdxCmd:     "conf-set",
+			ctlIdxSection: "zone",
+			ctlIdxItem:    "domain",
+			ctlIdxData:    normalizedZone,
+		}, nil); err != nil {
+			return fmt.Errorf("failed to add zone %s to configuration: %w", zone, err)
+		}
+
+		if c.zoneTemplate.KnotTemplate != "" {
+			if err := c.execute(ctx, cn, ctlData{
+				ctlIdxCmd:     "conf-set",
+				ctlIdxSection: "zone",
+				ctlIdxID:      normalizedZone,
+				ctlIdxItem:    "template",
+				ctlIdxData:    c.zoneTemplate.KnotTemplate,
+			}, nil); err != nil {
+				return fmt.Errorf("failed to set template for zone %s: %w", zone, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write the initial zone contents
+	err = c.transaction(ctx, normalizedZone, func(t *txn) error {
+		if err := t.set(normalizedZone, c.zoneTemplate.TTL, RecordTypeSOA, soa); err != nil {
+			return fmt.Errorf("failed to add SOA to zone %s: %w", zone, err)
+		}
+		for _, ns := range c.zoneTemplate.Nameservers {
+			if err := t.set(normalizedZone, c.zoneTemplate.TTL, RecordTypeNS, normalizeZoneName(ns)); err != nil {
+				return fmt.Errorf("failed to add NS to zone %s: %w", zone, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Do not leave a configured zone without contents behind
+		if rollbackErr := c.removeZoneConfig(ctx, normalizedZone); rollbackErr != nil {
+			c.logger.Errorf("Failed to roll back configuration of zone %s: %v", zone, rollbackErr)
+		}
+		return err
+	}
+
+	c.logger.Infof("Created zone: %s", zone)
+	return nil
+}
+
+// DeleteZone removes a zone from the knotd configuration. With purge, the
+// zone file, journal and other zone data are removed as well.
+func (c *Client) DeleteZone(ctx context.Context, zone string, purge bool) error {
+	if !c.IsZoneAllowed(zone) {
+		return fmt.Errorf("%w: %s", ErrZoneNotAllowed, zone)
+	}
+
+	unlock, err := c.lockZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	normalizedZone := strings.ToLower(normalizeZoneName(zone))
+	exists, err := c.hasZone(ctx, normalizedZone)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrZoneNotFound, zone)
+	}
+
+	// Purge zone data while knotd still knows the zone
+	if purge {
+		if err := c.command(ctx, ctlData{ctlIdxCmd: "zone-purge", ctlIdxZone: normalizedZone, ctlIdxFlags: "F"}, nil); err != nil {
+			return fmt.Errorf("failed to purge zone %s: %w", zone, err)
+		}
+	}
+
+	if err := c.removeZoneConfig(ctx, normalizedZone); err != nil {
+		return err
+	}
+
+	c.logger.Infof("Deleted zone: %s (purge: %t)", zone, purge)
+	return nil
+}
+
+// removeZoneConfig removes a zone section from the configuration
+func (c *Client) removeZoneConfig(ctx context.Context, zone string) error {
+	return c.confTransaction(ctx, func(cn conn) error {
+		if err := c.execute(ctx, cn, ctlData{
+			ctlIdxCmd:     "conf-unset",
+			ctlIdxSection: "zone",
+			ctlIdxID:      zone,
+		}, nil); err != nil {
+			return fmt.Errorf("failed to remove zone %s from configuration: %w", zone, err)
+		}
+		return nil
+	})
+}
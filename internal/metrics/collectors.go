@@ -0,0 +1,231 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the latency histogram buckets in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// key returns a map key for a set of label values
+func key(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	series map[string]*counter
+}
+
+type counter struct {
+	labels []string
+	value  float64
+}
+
+// NewCounterVec creates a counter with the given label names
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labelNames: labelNames, series: make(map[string]*counter)}
+}
+
+// Inc increments the counter for the given label values
+func (v *CounterVec) Inc(labelValues ...string) {
+	v.Add(1, labelValues...)
+}
+
+// Add adds delta to the counter for the given label values
+func (v *CounterVec) Add(delta float64, labelValues ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	k := key(labelValues)
+	c, ok := v.series[k]
+	if !ok {
+		c = &counter{labels: append([]string(nil), labelValues...)}
+		v.series[k] = c
+	}
+	c.value += delta
+}
+
+// Write writes the counter in Prometheus text format
+func (v *CounterVec) Write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	writeHeader(w, v.name, v.help, "counter")
+	for _, k := range sortedKeys(v.series) {
+		c := v.series[k]
+		fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labelNames, c.labels), formatValue(c.value))
+	}
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+type histogram struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates a histogram with the given buckets and label names
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return &HistogramVec{name: name, help: help, labelNames: labelNames, buckets: buckets, series: make(map[string]*histogram)}
+}
+
+// Observe records a value for the given label values
+func (v *HistogramVec) Observe(value float64, labelValues ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	k := key(labelValues)
+	h, ok := v.series[k]
+	if !ok {
+		h = &histogram{
+			labels: append([]string(nil), labelValues...),
+			counts: make([]uint64, len(v.buckets)),
+		}
+		v.series[k] = h
+	}
+
+	for i, bound := range v.buckets {
+		if value <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// Write writes the histogram in Prometheus text format
+func (v *HistogramVec) Write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	writeHeader(w, v.name, v.help, "histogram")
+	labelNames := append(append([]string(nil), v.labelNames...), "le")
+	for _, k := range sortedKeys(v.series) {
+		h := v.series[k]
+
+		var cumulative uint64
+		for i, bound := range v.buckets {
+			cumulative += h.counts[i]
+			labels := append(append([]string(nil), h.labels...), formatValue(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, formatLabels(labelNames, labels), cumulative)
+		}
+		labels := append(append([]string(nil), h.labels...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, formatLabels(labelNames, labels), h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.name, formatLabels(v.labelNames, h.labels), formatValue(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.name, formatLabels(v.labelNames, h.labels), h.count)
+	}
+}
+
+// Gauge is a single gauge sample with its own labels, used for values
+// collected at scrape time
+type Gauge struct {
+	Name   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+}
+
+// WriteGauges writes gauges in Prometheus text format, grouped by name
+func WriteGauges(w io.Writer, gauges []Gauge) {
+	sort.SliceStable(gauges, func(i, j int) bool { return gauges[i].Name < gauges[j].Name })
+
+	last := ""
+	for _, g := range gauges {
+		if g.Name != last {
+			writeHeader(w, g.Name, g.Help, "gauge")
+			last = g.Name
+		}
+
+		names := make([]string, 0, len(g.Labels))
+		for name := range g.Labels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]string, len(names))
+		for i, name := range names {
+			values[i] = g.Labels[name]
+		}
+
+		fmt.Fprintf(w, "%s%s %s\n", g.Name, formatLabels(names, values), formatValue(g.Value))
+	}
+}
+
+// SanitizeName turns s into a valid metric name component
+func SanitizeName(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9' && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// labelEscaper escapes label values as required by the text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		parts = append(parts, name+`="`+labelEscaper.Replace(value)+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
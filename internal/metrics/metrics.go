@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"io"
+	"strconv"
+	"time"
+)
+
+// Metrics holds the metrics collected by hyprknot itself. All methods are
+// safe to call on a nil *Metrics, which discards observations.
+type Metrics struct {
+	httpRequests    *CounterVec
+	httpDuration    *HistogramVec
+	commands        *CounterVec
+	commandFailures *CounterVec
+	commandDuration *HistogramVec
+	txnAborts       *CounterVec
+}
+
+// New creates an empty set of metrics
+func New() *Metrics {
+	return &Metrics{
+		httpRequests: NewCounterVec("hyprknot_http_requests_total",
+			"HTTP requests by method, route and status.", "method", "route", "status"),
+		httpDuration: NewHistogramVec("hyprknot_http_request_duration_seconds",
+			"HTTP request latency by method, route and status.", DefaultBuckets, "method", "route", "status"),
+		commands: NewCounterVec("hyprknot_knot_commands_total",
+			"Control commands sent to knotd by command.", "command"),
+		commandFailures: NewCounterVec("hyprknot_knot_command_failures_total",
+			"Control commands that failed by command.", "command"),
+		commandDuration: NewHistogramVec("hyprknot_knot_command_duration_seconds",
+			"Control command duration by command.", DefaultBuckets, "command"),
+		txnAborts: NewCounterVec("hyprknot_zone_transaction_aborts_total",
+			"Zone transactions aborted by zone.", "zone"),
+	}
+}
+
+// ObserveHTTP records a handled HTTP request
+func (m *Metrics) ObserveHTTP(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	code := strconv.Itoa(status)
+	m.httpRequests.Inc(method, route, code)
+	m.httpDuration.Observe(duration.Seconds(), method, route, code)
+}
+
+// ObserveCommand records a control command sent to knotd
+func (m *Metrics) ObserveCommand(command string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.commands.Inc(command)
+	m.commandDuration.Observe(duration.Seconds(), command)
+	if err != nil {
+		m.commandFailures.Inc(command)
+	}
+}
+
+// TransactionAborted records an aborted zone transaction
+func (m *Metrics) TransactionAborted(zone string) {
+	if m == nil {
+		return
+	}
+	m.txnAborts.Inc(zone)
+}
+
+// Write writes all metrics in Prometheus text format
+func (m *Metrics) Write(w io.Writer) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.Write(w)
+	m.httpDuration.Write(w)
+	m.commands.Write(w)
+	m.commandFailures.Write(w)
+	m.commandDuration.Write(w)
+	m.txnAborts.Write(w)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/hypr-technologies/hyprknot/internal/config"
 	"github.com/hypr-technologies/hyprknot/internal/knot"
 	"github.com/hypr-technologies/hyprknot/internal/logger"
+	"github.com/hypr-technologies/hyprknot/internal/metrics"
 )
 
 const (
@@ -60,22 +61,50 @@ func main() {
 	log.Infof("Starting %s version %s", appName, appVersion)
 	log.Infof("Configuration loaded from: %s", *configPath)
 
-	// Initialize KnotDNS client
-	knotClient := knot.NewClient(
-		cfg.Knot.KnotcPath,
-		cfg.Knot.SocketPath,
-		cfg.Knot.AllowedZones,
-		log,
-	)
+	// Initialize backend
+	m := metrics.New()
+	tmpl := cfg.Knot.ZoneTemplate
+	opts := knot.Options{
+		Transport:    cfg.Knot.Transport,
+		KnotcPath:    cfg.Knot.KnotcPath,
+		SocketPath:   cfg.Knot.SocketPath,
+		AllowedZones: cfg.Knot.AllowedZones,
+		ZoneTemplate: knot.ZoneTemplate{
+			KnotTemplate: tmpl.KnotTemplate,
+			TTL:          tmpl.TTL,
+			MName:        tmpl.MName,
+			RName:        tmpl.RName,
+			Refresh:      tmpl.Refresh,
+			Retry:        tmpl.Retry,
+			Expire:       tmpl.Expire,
+			Minimum:      tmpl.Minimum,
+			Nameservers:  tmpl.Nameservers,
+		},
+		SerialPolicy:   cfg.Knot.SerialPolicy,
+		SerialPolicies: cfg.Knot.SerialPolicies,
+		Metrics:        m,
+
+		CommandTimeout:  time.Duration(cfg.Knot.CommandTimeout) * time.Second,
+		CommandTimeouts: cfg.GetCommandTimeouts(),
+	}
+
+	var backend knot.Backend
+	switch cfg.Knot.Backend {
+	case "memory":
+		backend = knot.NewMemoryBackend(cfg.GetMemoryZones(), opts, log)
+		log.Warn("Using in-memory backend, changes will not be persisted")
+	default:
+		backend = knot.NewClient(opts, log)
+	}
 
-	// Test KnotDNS connection
-	if err := knotClient.CheckHealth(); err != nil {
+	// Test backend connection
+	if err := backend.CheckHealth(context.Background()); err != nil {
 		log.Fatalf("KnotDNS health check failed: %v", err)
 	}
-	log.Info("KnotDNS connection established")
+	log.Infof("Backend ready (backend: %s, transport: %s)", cfg.Knot.Backend, cfg.Knot.Transport)
 
 	// Setup routes
-	router := api.SetupRoutes(cfg, knotClient, log)
+	router := api.SetupRoutes(cfg, backend, m, log)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -139,20 +168,44 @@ CONFIGURATION:
 
 API ENDPOINTS:
     GET  /health                                    - Health check
-    GET  /api/v1/zones                             - List zones
-    GET  /api/v1/zones/{zone}/records              - List records in zone
+    GET  /metrics                                   - Prometheus metrics
+    GET  /api/v1/zones                             - List zones (?status=true for status)
+    GET  /api/v1/zones/{zone}/status               - Get zone status
+    POST /api/v1/zones                             - Create zone from the zone template
+    DELETE /api/v1/zones/{zone}                    - Delete zone (?purge=true removes its data)
+    GET  /api/v1/zones/{zone}/records              - List records in zone (?name=&type=)
     GET  /api/v1/zones/{zone}/records/{name}/{type} - Get specific record
     POST /api/v1/zones/{zone}/records              - Create record
     PUT  /api/v1/zones/{zone}/records/{name}/{type} - Update record
-    DELETE /api/v1/zones/{zone}/records/{name}/{type} - Delete record
+    DELETE /api/v1/zones/{zone}/records/{name}/{type} - Delete record (?data= for one value)
+    GET  /api/v1/zones/{zone}/rrsets/{name}/{type}  - Get all values of an RRset
+    PUT  /api/v1/zones/{zone}/rrsets/{name}/{type}  - Replace an RRset
+    POST /api/v1/zones/{zone}/rrsets/{name}/{type}/values - Add a value to an RRset
+    DELETE /api/v1/zones/{zone}/rrsets/{name}/{type}/values?value= - Remove a value
     POST /api/v1/zones/{zone}/reload               - Reload zone
+    POST /api/v1/zones/{zone}/freeze               - Freeze zone and enter maintenance
+    POST /api/v1/zones/{zone}/thaw                 - Thaw zone and leave maintenance
+    POST /api/v1/zones/{zone}/flush                - Flush zone to its zone file
+    POST /api/v1/zones/{zone}/purge?confirm={zone} - Purge zone data
+    PUT  /api/v1/zones/{zone}/maintenance          - Turn maintenance on or off
+    POST /api/v1/zones/{zone}/refresh              - Refresh secondary zone
+    POST /api/v1/zones/{zone}/retransfer           - Retransfer secondary zone
+    POST /api/v1/zones/{zone}/notify               - Send NOTIFY to remotes
+    POST /api/v1/zones/{zone}/changes              - Apply several changes atomically
+    GET  /api/v1/zones/{zone}/soa                  - Get SOA fields
+    PUT  /api/v1/zones/{zone}/soa                  - Update SOA fields and serial
+    POST /api/v1/zones/{zone}/dnssec/sign          - Re-sign zone
+    POST /api/v1/zones/{zone}/dnssec/rollover/{ksk|zsk} - Start key rollover
+    POST /api/v1/zones/{zone}/dnssec/ksk-submitted - Confirm KSK submission
+    GET  /api/v1/zones/{zone}/dnssec/keys          - List DNSKEY/CDS/CDNSKEY
+    GET  /api/v1/zones/{zone}/ds                   - Derive DS records
 
 AUTHENTICATION:
     API endpoints (except /health) require authentication via API key.
     Include the API key in the X-API-Key header or Authorization: Bearer header.
 
 SUPPORTED RECORD TYPES:
-    A, AAAA, PTR, CNAME, MX, TXT, NS
+    A, AAAA, PTR, CNAME, MX, TXT, NS, SRV, CAA, TLSA, SSHFP, DS, SVCB, HTTPS
 
 For more information, visit: https://github.com/hyprknot/hyprknot
 `, appName, appName, appName, appName)